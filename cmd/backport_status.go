@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/Kong/changelog/utils"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatCSV      = "csv"
+	formatJSON     = "json"
+)
+
+func shortSHA(sha string) string {
+	if len(sha) > 10 {
+		return sha[:10]
+	}
+	return sha
+}
+
+// markdownCell flattens text into a single Markdown table cell.
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+func writeBackportMarkdown(w io.Writer, statuses []changelog.BackportStatus, branches []string) error {
+	header := append([]string{"Entry", "Message"}, branches...)
+	fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(header)))
+
+	for _, status := range statuses {
+		cells := []string{markdownCell(status.File), markdownCell(status.Message)}
+		for _, branch := range branches {
+			presence := status.Branches[branch]
+			if presence == nil {
+				cells = append(cells, "no")
+				continue
+			}
+			cells = append(cells, fmt.Sprintf("yes (`%s`, %s)", shortSHA(presence.Commit), presence.Via))
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBackportCSV(w io.Writer, statuses []changelog.BackportStatus, branches []string) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(append([]string{"file", "message", "commit"}, branches...)); err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		record := []string{status.File, status.Message, status.Commit}
+		for _, branch := range branches {
+			if presence := status.Branches[branch]; presence != nil {
+				record = append(record, presence.Commit)
+			} else {
+				record = append(record, "")
+			}
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func writeBackportJSON(w io.Writer, statuses []changelog.BackportStatus) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(statuses)
+}
+
+func newBackportStatusCmd() *cli.Command {
+	cmd := &cli.Command{
+		Name:        "backport-status",
+		Description: "The backport-status command reports, for each entry in the changelog folders, on which branches its change is present",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "repo-path",
+				Usage:    "The repository path (/path/to/your/repository)",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     "changelog-paths",
+				Usage:    "The changelog folder relative paths (changelog/unreleased/kong)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "ref",
+				Usage:    "The git ref the changelog folders are read at",
+				Value:    "HEAD",
+				Required: false,
+			},
+			&cli.StringSliceFlag{
+				Name:     "branch",
+				Usage:    "A branch to report on (origin/next/3.14.x.x); repeat for several",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "format",
+				Usage:    "The output format (markdown, csv, json)",
+				Value:    formatMarkdown,
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "patch-id",
+				Usage:    "Also match changes by git patch-id, for backports made without cherry-pick -x: over the entry's changelog file (changelog) or the whole commit (full)",
+				Required: false,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			repoPath := c.String("repo-path")
+			ref := c.String("ref")
+			branches := c.StringSlice("branch")
+			for _, r := range append([]string{ref}, branches...) {
+				if !utils.RefExists(repoPath, r) {
+					return fmt.Errorf("git ref %q not found in %s", r, repoPath)
+				}
+			}
+
+			format := c.String("format")
+			if format != formatMarkdown && format != formatCSV && format != formatJSON {
+				return fmt.Errorf("unknown format %q, must be one of: %s, %s, %s", format, formatMarkdown, formatCSV, formatJSON)
+			}
+
+			if err := changelog.ValidatePatchIDMode(c.String("patch-id")); err != nil {
+				return err
+			}
+
+			changelogPaths := c.StringSlice("changelog-paths")
+			finder := changelog.NewProvenanceFinder(repoPath, ref, c.String("patch-id"), changelogPaths, logger)
+			defer finder.Close()
+			statuses, err := finder.CollectBackportStatus(ref, changelogPaths, branches)
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case formatCSV:
+				return writeBackportCSV(c.App.Writer, statuses, branches)
+			case formatJSON:
+				return writeBackportJSON(c.App.Writer, statuses)
+			default:
+				return writeBackportMarkdown(c.App.Writer, statuses, branches)
+			}
+		},
+	}
+
+	return cmd
+}
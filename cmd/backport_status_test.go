@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Kong/changelog/pkg/changelog"
+)
+
+// newBackportFixtureRepo creates a repository whose main line has three
+// entries, and a release branch cut after the first one that backports the
+// second with cherry-pick -x and lacks the third. It returns the repository
+// and the commits adding each entry, then the backport commit.
+func newBackportFixtureRepo(t *testing.T) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	commitFile(t, dir, "README.md", "kong\n", "init")
+	present := commitFile(t, dir, "changelog/unreleased/kong/present.yml",
+		"message: Present before the cut.\ntype: bugfix\n", "fix: present (#1)")
+	runGit(t, dir, "branch", "next/3.14.x.x")
+	picked := commitFile(t, dir, "changelog/unreleased/kong/picked.yml",
+		"message: Backported | with a pipe.\ntype: bugfix\n", "fix: picked (#2)")
+	missing := commitFile(t, dir, "changelog/unreleased/kong/missing.yml",
+		"message: Not backported.\ntype: feature\n", "feat: missing (#3)")
+
+	runGit(t, dir, "checkout", "-q", "next/3.14.x.x")
+	runGit(t, dir, "cherry-pick", "-x", picked)
+	backport := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "checkout", "-q", "-")
+	return dir, []string{present, picked, missing, backport}
+}
+
+func TestBackportStatus(t *testing.T) {
+	dir, commits := newBackportFixtureRepo(t)
+	present, picked, backport := commits[0], commits[1], commits[3]
+	args := []string{"backport-status",
+		"--repo-path", dir,
+		"--changelog-paths", "changelog/unreleased/kong",
+		"--branch", "next/3.14.x.x",
+	}
+
+	out, err := runApp(t, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "backport_status.md", out)
+
+	out, err = runApp(t, append(args, "--format", "json")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []changelog.BackportStatus
+	if err := json.Unmarshal([]byte(out), &statuses); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*changelog.Presence{
+		"changelog/unreleased/kong/present.yml": {Commit: present, Via: "ancestor"},
+		"changelog/unreleased/kong/picked.yml":  {Commit: backport, Via: "cherry-pick"},
+		"changelog/unreleased/kong/missing.yml": nil,
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("statuses = %+v, want %d", statuses, len(want))
+	}
+	for _, status := range statuses {
+		got, wantPresence := status.Branches["next/3.14.x.x"], want[status.File]
+		if (got == nil) != (wantPresence == nil) || (got != nil && *got != *wantPresence) {
+			t.Fatalf("%s on next/3.14.x.x = %+v, want %+v", status.File, got, wantPresence)
+		}
+	}
+
+	out, err = runApp(t, append(args, "--format", "csv")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := map[string][]string{}
+	for _, record := range records[1:] {
+		rows[record[0]] = record
+	}
+	if got := strings.Join(records[0], ","); got != "file,message,commit,next/3.14.x.x" {
+		t.Fatalf("CSV header = %q", got)
+	}
+	if row := rows["changelog/unreleased/kong/picked.yml"]; len(row) != 4 || row[1] != "Backported | with a pipe." || row[2] != picked || row[3] != backport {
+		t.Fatalf("CSV row of picked.yml = %q", row)
+	}
+	if row := rows["changelog/unreleased/kong/missing.yml"]; len(row) != 4 || row[3] != "" {
+		t.Fatalf("CSV row of missing.yml = %q", row)
+	}
+}
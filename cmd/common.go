@@ -4,11 +4,21 @@ import (
 	"net/http"
 )
 
+// LoggingTransport logs the GitHub API requests at the debug level.
 type LoggingTransport struct {
 	Transport http.RoundTripper
 }
 
 func (t *LoggingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
-	Info("curl '%s' -H 'Authorization: %s'", request.URL, "ghp_******")
+	logger.Debug("github request", "method", request.Method, "url", request.URL.String())
 	return t.Transport.RoundTrip(request)
 }
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\nm\n"
+	want := `--- file.yml
++++ file.yml
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,5 +9,5 @@
+ i
+ j
+ k
+-l
++L
+ m
+`
+	if got := unifiedDiff("file.yml", before, after); got != want {
+		t.Fatalf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("file.yml", before, before); got != "" {
+		t.Fatalf("unifiedDiff() of equal content = %q, want none", got)
+	}
+}
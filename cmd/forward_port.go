@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/Kong/changelog/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func newForwardPortCheckCmd() *cli.Command {
+	cmd := &cli.Command{
+		Name:        "forward-port-check",
+		Description: "The forward-port-check command lists the entries on a source branch whose change is missing from one or more target branches",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "repo-path",
+				Usage:    "The repository path (/path/to/your/repository)",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     "changelog-paths",
+				Usage:    "The changelog folder relative paths on the source branch (changelog/unreleased/kong)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "source",
+				Usage:    "The branch the fixes landed on (origin/next/3.14.0.9)",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     "target",
+				Usage:    "A branch the fixes must also exist on (origin/next/3.15.x.x); repeat for several",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "patch-id",
+				Usage:    "Also match changes by git patch-id, for backports made without cherry-pick -x: over the entry's changelog file (changelog) or the whole commit (full)",
+				Required: false,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			repoPath := c.String("repo-path")
+			source := c.String("source")
+			targets := c.StringSlice("target")
+			for _, ref := range append([]string{source}, targets...) {
+				if !utils.RefExists(repoPath, ref) {
+					return fmt.Errorf("git ref %q not found in %s", ref, repoPath)
+				}
+			}
+
+			if err := changelog.ValidatePatchIDMode(c.String("patch-id")); err != nil {
+				return err
+			}
+
+			changelogPaths := c.StringSlice("changelog-paths")
+			finder := changelog.NewProvenanceFinder(repoPath, source, c.String("patch-id"), changelogPaths, logger)
+			defer finder.Close()
+			gaps, err := finder.FindForwardPortGaps(source, targets, changelogPaths)
+			if err != nil {
+				return err
+			}
+
+			if len(gaps) == 0 {
+				logger.Info("all changelog entries are present on the targets", "source", source, "targets", strings.Join(targets, ", "))
+				return nil
+			}
+
+			for i, gap := range gaps {
+				fmt.Fprintf(c.App.Writer, "%d. file: %s\n   commit: %s\n   missing on: %s\n", i+1, gap.Entry.File, gap.Entry.Commit, strings.Join(gap.Missing, ", "))
+			}
+
+			entryNoun := "entries"
+			if len(gaps) == 1 {
+				entryNoun = "entry"
+			}
+			return fmt.Errorf("%d changelog %s on %s not forward-ported", len(gaps), entryNoun, source)
+		},
+	}
+
+	return cmd
+}
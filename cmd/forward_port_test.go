@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestForwardPortCheck(t *testing.T) {
+	// main carries the three entries: next/3.14.x.x has present.yml as an
+	// ancestor, picked.yml cherry-picked and lacks missing.yml, while
+	// next/3.15.x.x is cut after all of them.
+	dir, commits := newBackportFixtureRepo(t)
+	missing := commits[2]
+	runGit(t, dir, "branch", "fixes")
+	runGit(t, dir, "branch", "next/3.15.x.x")
+	args := []string{"forward-port-check",
+		"--repo-path", dir,
+		"--changelog-paths", "changelog/unreleased/kong",
+		"--source", "fixes",
+	}
+
+	out, err := runApp(t, append(args, "--target", "next/3.15.x.x", "--target", "next/3.14.x.x")...)
+	if err == nil || err.Error() != "1 changelog entry on fixes not forward-ported" {
+		t.Fatalf("err = %v, want the missing entry to fail the check", err)
+	}
+	want := fmt.Sprintf("1. file: changelog/unreleased/kong/missing.yml\n   commit: %s\n   missing on: next/3.14.x.x\n", missing)
+	if out != want {
+		t.Fatalf("output = %q, want %q", out, want)
+	}
+
+	out, err = runApp(t, append(args, "--target", "next/3.15.x.x")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Fatalf("output = %q, want no gaps", out)
+	}
+}
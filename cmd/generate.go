@@ -1,697 +1,292 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
-	"embed"
-	_ "embed"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
-	"text/template"
 
+	"github.com/Kong/changelog/pkg/changelog"
 	"github.com/Kong/changelog/utils"
 	"github.com/google/go-github/v56/github"
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v3"
 )
 
-const (
-	jiraBaseURL = "https://konghq.atlassian.net/browse/"
-)
-
-//go:generate cp -f ../changelog-markdown.tmpl changelog-markdown.tmpl
-//go:embed changelog-markdown.tmpl
-var changelogTmplFS embed.FS
-
-type GenerateCmdOptions struct {
-	Title string
-
-	RepoPath string
-
-	ChangelogPaths []string
-
-	// SourceBranch is the minor branch that the fix-release branch being
-	// generated was cut from (e.g. origin/next/3.14.x.x). When set, every entry
-	// is attributed to the PR of its commit on this minor branch — the
-	// release-line (backport) PR — rather than the upstream/master PR or the
-	// sync PR. Empty uses the introducing commit directly (backward-compatible).
-	SourceBranch string
-
-	WithJiras bool
-
-	GithubApiOwner string
-	GithubApiRepo  string
-
-	GithubIssueRepo string
-}
-
-// global vars
-var (
-	options GenerateCmdOptions
-
-	ScopePriority = map[string]int{
-		"Performance":   10,
-		"Configuration": 20,
-		"Core":          30,
-		"PDK":           40,
-		"Plugin":        50,
-		"Admin API":     60,
-		"Clustering":    70,
-		"Default":       100, // default priority
-	}
-	client *github.Client
-)
-
-type CommitContext struct {
-	SHA     string
-	Message string
-	PrCtx   PullRequestContext
-}
-
-type PullRequestContext struct {
-	Number int
-	Title  string
-	Body   string
-}
-
-type MissingPullRequestError struct {
-	CommitSHA string
-}
-
-func (e *MissingPullRequestError) Error() string {
-	return fmt.Sprintf("no merged PR found for commit %s", e.CommitSHA)
-}
-
-type EntryProcessingFailure struct {
-	FileName  string
-	CommitSHA string
-	Err       error
-}
-
-func (e *EntryProcessingFailure) Error() string {
-	var missingPR *MissingPullRequestError
-	if errors.As(e.Err, &missingPR) {
-		return fmt.Sprintf("reason: missing merged PR\nfile: %s\ncommit: %s", e.FileName, missingPR.CommitSHA)
-	}
-
-	if e.CommitSHA == "" {
-		return fmt.Sprintf("file: %s\nerror: %v", e.FileName, e.Err)
-	}
-
-	return fmt.Sprintf("file: %s\ncommit: %s\nerror: %v", e.FileName, e.CommitSHA, e.Err)
-}
-
-func (e *EntryProcessingFailure) Unwrap() error {
-	return e.Err
-}
-
-func logEntryProcessingFailure(failure EntryProcessingFailure) {
-	Error("skipping changelog entry: %s\n", strings.ReplaceAll(failure.Error(), "\n", "\n  "))
-}
-
-func logEntryProcessingSummary(failures []EntryProcessingFailure) {
-	if len(failures) == 0 {
-		return
-	}
-
-	entryNoun := "entries"
-	if len(failures) == 1 {
-		entryNoun = "entry"
-	}
-
-	Error("\nskipped %d changelog %s:\n", len(failures), entryNoun)
-	for i, failure := range failures {
-		Error("%d. %s\n", i+1, strings.ReplaceAll(failure.Error(), "\n", "\n   "))
-	}
-}
-
-func entryProcessingFailureFromError(err error, fileName string) *EntryProcessingFailure {
-	var failure *EntryProcessingFailure
-	if errors.As(err, &failure) {
-		if failure.FileName == "" {
-			failure.FileName = fileName
-		}
-		if failure.CommitSHA == "" {
-			var missingPR *MissingPullRequestError
-			if errors.As(failure.Err, &missingPR) {
-				failure.CommitSHA = missingPR.CommitSHA
-			}
-		}
-		return failure
-	}
-
-	var missingPR *MissingPullRequestError
-	if !errors.As(err, &missingPR) {
-		return nil
-	}
-
-	return &EntryProcessingFailure{
-		FileName:  fileName,
-		CommitSHA: missingPR.CommitSHA,
-		Err:       err,
-	}
-}
-
-var pullRequestRefPattern = regexp.MustCompile(`\(#(\d+)\)`)
-
-func isYAML(filename string) bool {
-	return strings.HasSuffix(filename, ".yml")
-}
-
-func findMergedPullRequest(prs []*github.PullRequest) *github.PullRequest {
-	for i := len(prs) - 1; i >= 0; i-- {
-		if prs[i].MergedAt != nil {
-			return prs[i]
-		}
-	}
-
-	return nil
-}
-
-func fetchMergedPullRequestFromCommitMessage(commit string) (*github.PullRequest, error) {
-	repoCommit, _, err := client.Repositories.GetCommit(context.TODO(), options.GithubApiOwner, options.GithubApiRepo, commit, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read commit message for %s: %v", commit, err)
-	}
-
-	matches := pullRequestRefPattern.FindAllStringSubmatch(repoCommit.GetCommit().GetMessage(), -1)
-	if len(matches) == 0 {
-		return nil, nil
-	}
-
-	seen := make(map[int]struct{}, len(matches))
-	for i := len(matches) - 1; i >= 0; i-- {
-		prNumber, err := strconv.Atoi(matches[i][1])
-		if err != nil {
-			continue
-		}
-
-		if _, ok := seen[prNumber]; ok {
-			continue
-		}
-		seen[prNumber] = struct{}{}
-
-		pr, resp, err := client.PullRequests.Get(context.TODO(), options.GithubApiOwner, options.GithubApiRepo, prNumber)
-		if err != nil {
-			if debug && (resp == nil || resp.StatusCode != http.StatusNotFound) {
-				Debug("failed to fetch PR #%d for commit %s: %v", prNumber, commit, err)
-			}
-			continue
-		}
-		if pr.MergedAt == nil {
-			continue
-		}
-		if pr.GetMergeCommitSHA() == commit {
-			return pr, nil
-		}
-	}
-
-	return nil, nil
-}
-
-// resolveMergedPR finds the merged PR that introduced the given commit, first
-// via the GitHub "list PRs associated with a commit" API and, failing that, by
-// parsing PR references out of the commit message.
-func resolveMergedPR(commit string) (*github.PullRequest, error) {
-	prs, _, err := client.PullRequests.ListPullRequestsWithCommit(context.TODO(), options.GithubApiOwner, options.GithubApiRepo, commit, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch pulls for commit %s: %v", commit, err)
-	}
-
-	mergedPR := findMergedPullRequest(prs)
-	if mergedPR == nil {
-		mergedPR, err = fetchMergedPullRequestFromCommitMessage(commit)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve merged PR from commit message: %v", err)
-		}
-		if debug && mergedPR != nil {
-			Debug("resolved merged PR #%d from commit message for %s", mergedPR.GetNumber(), commit)
-		}
-	}
-
-	return mergedPR, nil
-}
-
-// releaseLineCandidates returns the commit SHAs to resolve a changelog entry's
-// PR from, in priority order, so the entry is attributed to the PR of its commit
-// on the minor branch (options.SourceBranch, e.g. next/3.14.x.x) — the
-// release-line PR — rather than the upstream/master PR or the sync PR.
-//
-// A fix-release branch (e.g. next/3.14.0.9) is cut from the minor branch and
-// then synced by cherry-picking. The introducing commit is either:
-//
-//   - already on the minor branch (present before the cut) — use it as-is; or
-//   - synced in after the cut — locate its counterpart on the minor branch by,
-//     in order: (1) the `git cherry-pick -x` trailer, when the recorded source
-//     is itself on the minor branch; (2) the minor-branch commit that
-//     cherry-picked the same upstream source (the backport commit); (3) the
-//     minor-branch commit that introduced the entry's changelog file, which is
-//     cherry-picked verbatim during the sync and so identifies the counterpart
-//     even when no trailer was recorded (e.g. a backport applied without -x) and
-//     regardless of what else the sync commit touched.
-//
-// The introducing commit is always kept as the last fallback so an entry is
-// never dropped when the preferred commit has no resolvable merged PR.
-func releaseLineCandidates(commit, filename string) []string {
-	if options.SourceBranch == "" || utils.IsAncestor(options.RepoPath, commit, options.SourceBranch) {
-		return []string{commit}
-	}
-
-	// Prefer the recorded cherry-pick provenance (exact and cheap): the source
-	// itself when it is on the minor branch, else the minor-branch commit that
-	// backported the same source.
-	src := utils.FindCherryPickSource(options.RepoPath, commit)
-	if src != "" {
-		if utils.IsAncestor(options.RepoPath, src, options.SourceBranch) {
-			return []string{src, commit}
-		}
-		if onBranch := utils.FindCherryPickOnBranch(options.RepoPath, options.SourceBranch, src); onBranch != "" {
-			return []string{onBranch, commit}
-		}
-	}
-
-	// No usable trailer mapping (no trailer, or the source is upstream with no
-	// minor-branch backport referencing it): find the minor-branch commit that
-	// introduced this entry's changelog file.
-	if origin := utils.FindFileOriginOnBranch(options.RepoPath, options.SourceBranch, filename); origin != "" {
-		return []string{origin, commit}
-	}
-
-	// Last resort: the recorded upstream source (if any) as the best available
-	// origin, otherwise the introducing commit itself.
-	if src != "" {
-		return []string{src, commit}
-	}
-	return []string{commit}
-}
-
-func fetchCommitContext(filename string) (ctx CommitContext, err error) {
-	commit, err := utils.FindOriginalCommit(options.RepoPath, filename)
-	if err != nil {
-		return
-	}
-	ctx.SHA = commit
-	if debug {
-		Debug("file %s original commit: %s", filename, commit)
-	}
-
-	candidates := releaseLineCandidates(commit, filename)
-	if debug && candidates[0] != commit {
-		Debug("commit %s attributed to release-line commit %s (via %v)", commit, candidates[0], candidates)
-	}
-
-	var mergedPR *github.PullRequest
-	for _, sha := range candidates {
-		mergedPR, err = resolveMergedPR(sha)
-		if err != nil {
-			return ctx, err
-		}
-		if mergedPR != nil {
-			ctx.SHA = sha
-			break
-		}
-	}
-
-	if mergedPR == nil {
-		return ctx, &MissingPullRequestError{CommitSHA: ctx.SHA}
-	}
-
-	ctx.PrCtx = PullRequestContext{
-		Number: mergedPR.GetNumber(),
-		Title:  mergedPR.GetTitle(),
-		Body:   mergedPR.GetBody(),
+// generateFlags returns the flags shared by the commands that collect and
+// render entries (generate and release).
+func generateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "repo-path",
+			Usage:    "The repository path (/path/to/your/repository)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "changelog-path",
+			Usage:    "The changelog folder relative path (changelog/unreleased/kong)",
+			Required: false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "changelog-paths",
+			Usage:    "The changelog folder relative paths (changelog/unreleased/kong)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "github-issue-repo",
+			Usage:    "The repo name that is used to compose the GitHub issue link. (OWNER/REPO)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "github-api-repo",
+			Usage:    "The repo name that is used to compose the GitHub URL to retrieve data. (OWNER/REPO)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "template",
+			Usage:    "A custom Go template file to render instead of the built-in Markdown template",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "with-jiras",
+			Usage:    "Display Jira links",
+			Required: false,
+		},
+		&cli.BoolFlag{
+			Name:     "with-contributors",
+			Usage:    "Display a section thanking the community contributors whose PRs are included",
+			Required: false,
+		},
+		&cli.StringSliceFlag{
+			Name:     "source-branch",
+			Usage:    "The minor branch the fix-release branch was cut from (origin/next/3.14.x.x). When set, each entry is attributed to the PR of its commit on this branch (the release-line/backport PR) instead of the upstream/master or sync PR. Repeat for each further hop changes flow through, nearest first (an EE sync branch, origin/master); the nearest branch an entry is found on wins.",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "patch-id",
+			Usage:    "Also match entries to their --source-branch counterparts by git patch-id, for backports made without cherry-pick -x: over the entry's changelog file (changelog) or the whole commit (full)",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "config",
+			Usage:    "The changelog config file declaring the allowed editions and products (changelog/config.yml)",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "plugin-headings",
+			Usage:    "Render the Plugin scope with one sub-heading per plugin, listing entries that touch several plugins under each of them (each) or under one combined heading (combined)",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "since",
+			Usage:    "The tag of the previous release (3.14.0.8); entries already shipped in it are omitted",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "shipped",
+			Usage:    "What to do with the entries already shipped in --since: omit them, or flag them in the report but keep them (omit, flag)",
+			Value:    changelog.ShippedOmit,
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "reverted",
+			Usage:    "What to do with the entries whose commit or PR was reverted: omit them, keep them with a warning, or keep them with a Reverted note (omit, warn, note)",
+			Value:    changelog.RevertedWarn,
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "duplicates",
+			Usage:    "What to do with the entries repeating another one (same or similar message, or same PR and type): keep them with a warning, or merge those repeating its message, type, scope and plugins into it, combining their GitHub and Jira links (warn, merge)",
+			Value:    changelog.DuplicatesWarn,
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "edition",
+			Usage:    "Only include entries that apply to this edition (Enterprise)",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "record-http",
+			Usage:    "Record the GitHub API responses into this folder, to replay them with --replay-http",
+			Required: false,
+		},
+		&cli.StringFlag{
+			Name:     "replay-http",
+			Usage:    "Answer the GitHub API requests from the responses recorded into this folder by --record-http, without network; GITHUB_TOKEN is then not required",
+			Required: false,
+		},
+		githubActionsFlag(),
 	}
-
-	return ctx, nil
-}
-
-type ScopeEntries struct {
-	ScopeName string
-	Entries   []*ChangelogEntry
-}
-
-type TemplateData struct {
-	Title string
-	Type  map[string][]ScopeEntries
-}
-
-type Jira struct {
-	ID   string
-	Link string
 }
 
-type Github struct {
-	Name string
-	Link string
+// generateSetup is what the commands that collect and render entries need
+// to build a changelog.Generator, read from the flags of generateFlags.
+type generateSetup struct {
+	options  changelog.Options
+	config   changelog.Config
+	resolver changelog.PullRequestResolver
 }
 
-type ChangelogEntry struct {
-	Message       string   `yaml:"message"`
-	Type          string   `yaml:"type"`
-	Scope         string   `yaml:"scope"`
-	Prs           []int    `yaml:"prs"`
-	Githubs       []int    `yaml:"githubs"`
-	Jiras         []string `yaml:"jiras"`
-	ParsedJiras   []*Jira
-	ParsedGithubs []*Github
-	fileName      string
+func (s *generateSetup) generator() *changelog.Generator {
+	return changelog.NewGenerator(s.options, s.config, s.resolver, logger)
 }
 
-func parseGithub(githubNos []int) []*Github {
-	list := make([]*Github, 0)
-	for _, no := range githubNos {
-		github := &Github{
-			Name: fmt.Sprintf("#%d", no),
-			// Use the /pull/ form so the common case (a resolved PR or a `prs`
-			// entry) links straight to the PR. GitHub redirects /pull/<n> to
-			// /issues/<n> when <n> is actually an issue, so genuine issue
-			// references in the `githubs` field still resolve correctly.
-			Link: fmt.Sprintf("https://github.com/%s/pull/%d", options.GithubIssueRepo, no),
-		}
-		list = append(list, github)
+// setupGenerate loads the config, builds the GitHub client and the options
+// from the flags of generateFlags.
+func setupGenerate(c *cli.Context) (*generateSetup, error) {
+	recordDir, replayDir := c.String("record-http"), c.String("replay-http")
+	if recordDir != "" && replayDir != "" {
+		return nil, errors.New("--record-http and --replay-http are mutually exclusive")
 	}
-	return list
-}
 
-// processEntry process a changelog entry
-func processEntry(entry *ChangelogEntry) error {
-	if entry.Scope == "" {
-		entry.Scope = "Default"
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	if githubToken == "" && replayDir == "" {
+		return nil, errors.New("environment variable GITHUB_TOKEN is required")
 	}
 
-	ctx, err := fetchCommitContext(entry.fileName)
+	config, err := changelog.LoadConfig(c.String("config"))
 	if err != nil {
-		var missingPR *MissingPullRequestError
-		var noCommits *utils.NoCommitsFoundError
-		if !errors.As(err, &missingPR) && !errors.As(err, &noCommits) {
-			return fmt.Errorf("failed to fetch commit ctx: %v", err)
-		}
-
-		return &EntryProcessingFailure{
-			FileName:  entry.fileName,
-			CommitSHA: ctx.SHA,
-			Err:       fmt.Errorf("failed to fetch commit ctx: %w", err),
-		}
-	}
-
-	// jiras
-	if len(entry.Jiras) == 0 {
-		jiraMap := make(map[string]bool)
-		jiras := utils.MatchJiras(ctx.PrCtx.Body)
-		for _, jira := range jiras {
-			if !jiraMap[jira] {
-				entry.Jiras = append(entry.Jiras, jira)
-				jiraMap[jira] = true
-			}
-		}
-	}
-	if options.WithJiras {
-		for _, jiraId := range entry.Jiras {
-			jira := Jira{
-				ID:   jiraId,
-				Link: jiraBaseURL + jiraId,
-			}
-			entry.ParsedJiras = append(entry.ParsedJiras, &jira)
-		}
-	}
-
-	// githubs
-	if len(entry.Githubs) == 0 {
-		entry.Githubs = entry.Prs
-	}
-	if len(entry.Githubs) == 0 {
-		entry.Githubs = append(entry.Githubs, ctx.PrCtx.Number)
-	}
-
-	entry.ParsedGithubs = parseGithub(entry.Githubs)
-
-	return nil
-}
-
-func mapKeys(m map[string][]*ChangelogEntry) []string {
-	keys := make([]string, 0)
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
-}
-
-func collectFromFolder(repoPath string, changelogPath string, maps map[string]map[string][]*ChangelogEntry) ([]EntryProcessingFailure, error) {
-	failures := make([]EntryProcessingFailure, 0)
-	changelogPath = filepath.Join(repoPath, changelogPath)
-	exists, err := utils.DirExists(changelogPath)
-	if !exists {
-		return failures, err
-	}
-
-	files, err := os.ReadDir(changelogPath)
-	if err != nil {
-		return failures, err
+		return nil, err
 	}
-	total := len(files)
-	Info("reading files from folder %s", changelogPath)
-	for i := 1; i <= total; i++ {
-		file := files[i-1]
-		if file.IsDir() {
-			continue
-		}
-
-		if !isYAML(file.Name()) {
-			Debug("Skipping file: %s (%d/%d)", file.Name(), i, total)
-			continue
-		}
-
-		filePath := filepath.Join(changelogPath, file.Name())
 
-		content, err := os.ReadFile(filePath)
-		if err != nil {
-			return failures, err
-		}
-
-		Info("processing changelog file: %s (%d/%d)", file.Name(), i, total)
-
-		// parse entry
-		entry := &ChangelogEntry{}
-		err = yaml.Unmarshal(content, entry)
-		if err != nil {
-			return failures, fmt.Errorf("failed to unmarshal YAML from %s: %v", file.Name(), err)
-		}
-
-		entry.fileName = filePath
-
-		err = processEntry(entry)
-		if err != nil {
-			failure := entryProcessingFailureFromError(err, filePath)
-			if failure == nil {
-				return failures, fmt.Errorf("failed to process entry: %v", err)
-			}
-
-			logEntryProcessingFailure(*failure)
-			failures = append(failures, *failure)
+	repoPath := c.String("repo-path")
+	sourceBranches := make([]string, 0)
+	for _, sourceBranch := range c.StringSlice("source-branch") {
+		if !utils.RefExists(repoPath, sourceBranch) {
+			logger.Warn("source branch not found; cherry-pick source attribution through it disabled", "branch", sourceBranch, "repo", repoPath)
 			continue
 		}
-
-		if maps[entry.Type] == nil {
-			maps[entry.Type] = make(map[string][]*ChangelogEntry)
+		sourceBranches = append(sourceBranches, sourceBranch)
+	}
+
+	options := changelog.Options{
+		RepoPath:         repoPath,
+		ChangelogPaths:   c.StringSlice("changelog-paths"),
+		Title:            c.String("title"),
+		TemplatePath:     c.String("template"),
+		GithubIssueRepo:  c.String("github-issue-repo"),
+		WithJiras:        c.Bool("with-jiras"),
+		WithContributors: c.Bool("with-contributors"),
+		Infer:            c.Bool("infer"),
+		SourceBranches:   sourceBranches,
+		PatchID:          c.String("patch-id"),
+		PluginHeadings:   c.String("plugin-headings"),
+		Edition:          c.String("edition"),
+		Since:            c.String("since"),
+		Shipped:          c.String("shipped"),
+		Reverted:         c.String("reverted"),
+		Duplicates:       c.String("duplicates"),
+		FromRef:          c.String("from"),
+		ToRef:            c.String("to"),
+	}
+	if err := options.Validate(config); err != nil {
+		return nil, err
+	}
+	if options.Since != "" && !utils.RefExists(repoPath, options.Since) {
+		return nil, fmt.Errorf("git ref %q not found in %s", options.Since, repoPath)
+	}
+
+	var transport http.RoundTripper = http.DefaultTransport
+	if recordDir != "" {
+		transport = &RecordingTransport{
+			Transport: transport,
+			Dir:       recordDir,
 		}
-		maps[entry.Type][entry.Scope] = append(maps[entry.Type][entry.Scope], entry)
 	}
-
-	return failures, nil
-}
-
-func collect() (*TemplateData, []EntryProcessingFailure, error) {
-	maps := make(map[string]map[string][]*ChangelogEntry)
-	failures := make([]EntryProcessingFailure, 0)
-
-	for _, path := range options.ChangelogPaths {
-		folderFailures, err := collectFromFolder(options.RepoPath, path, maps)
-		failures = append(failures, folderFailures...)
-		if err != nil {
-			return nil, failures, err
+	if replayDir != "" {
+		transport = &ReplayTransport{
+			Dir: replayDir,
 		}
 	}
-
-	data := &TemplateData{
-		Type: make(map[string][]ScopeEntries),
-	}
-
-	//data.Type = make(map[string][]ScopeEntries)
-	for t, scopeEntries := range maps {
-		scopes := mapKeys(scopeEntries)
-		sort.Slice(scopes, func(i, j int) bool {
-			scopei := scopes[i]
-			scopej := scopes[j]
-			return ScopePriority[scopei] < ScopePriority[scopej]
-		})
-
-		list := make([]ScopeEntries, 0)
-		for _, scope := range scopes {
-			entries := ScopeEntries{
-				ScopeName: scope,
-				Entries:   scopeEntries[scope],
-			}
-			list = append(list, entries)
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		transport = &LoggingTransport{
+			Transport: transport,
 		}
-		data.Type[t] = list
 	}
+	httpClient := &http.Client{Transport: transport}
+	client := github.NewClient(httpClient).WithAuthToken(githubToken)
 
-	return data, failures, nil
-}
-
-func generate(data *TemplateData) error {
-	tmpl, err := template.New("changelog-markdown.tmpl").Funcs(template.FuncMap{
-		"arr": func(values ...any) []any { return values },
-		"dict": func(values ...any) (map[string]any, error) {
-			if len(values)%2 != 0 {
-				return nil, errors.New("invalid dictionary call")
-			}
-
-			root := make(map[string]any)
+	owner, repo, _ := strings.Cut(c.String("github-api-repo"), "/")
 
-			for i := 0; i < len(values); i += 2 {
-				dict := root
-				var key string
-				switch v := values[i].(type) {
-				case string:
-					key = v
-				case []string:
-					for i := 0; i < len(v)-1; i++ {
-						key = v[i]
-						var m map[string]any
-						v, found := dict[key]
-						if found {
-							m = v.(map[string]any)
-						} else {
-							m = make(map[string]any)
-							dict[key] = m
-						}
-						dict = m
-					}
-					key = v[len(v)-1]
-				default:
-					return nil, errors.New("invalid dictionary key")
-				}
-				dict[key] = values[i+1]
-			}
-
-			return root, nil
-		},
-		"trim": func(value string) string {
-			return strings.TrimSpace(value)
-		},
-	}).ParseFS(changelogTmplFS, "changelog-markdown.tmpl")
-	if err != nil {
-		return err
-	}
-	err = tmpl.Execute(os.Stdout, data)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-// Generate output the changelog
-func Generate() error {
-	Debug("Options: %+v", options)
-
-	data, failures, err := collect()
-	logEntryProcessingSummary(failures)
-	if err != nil {
-		return err
-	}
-
-	data.Title = options.Title
-	return generate(data)
+	return &generateSetup{
+		options:  options,
+		config:   config,
+		resolver: changelog.NewGitHubResolver(client, owner, repo, logger),
+	}, nil
 }
 
 func newGenerateCmd() *cli.Command {
 	cmd := &cli.Command{
 		Name:        "generate",
 		Description: "The generate command output the generated changelog markdown to /dev/stdout",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:     "repo-path",
-				Usage:    "The repository path (/path/to/your/repository)",
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "changelog-path",
-				Usage:    "The changelog folder relative path (changelog/unreleased/kong)",
-				Required: false,
-			},
+		Flags: append(generateFlags(),
 			&cli.StringFlag{
 				Name:     "title",
 				Usage:    "The title name (Kong)",
 				Required: true,
 			},
-			&cli.StringSliceFlag{
-				Name:     "changelog-paths",
-				Usage:    "The changelog folder relative paths (changelog/unreleased/kong)",
-				Required: true,
-			},
 			&cli.StringFlag{
-				Name:     "github-issue-repo",
-				Usage:    "The repo name that is used to compose the GitHub issue link. (OWNER/REPO)",
-				Required: true,
+				Name:     "from",
+				Usage:    "Only include the entries added or modified since this git ref (3.14.0.8), read from the git history instead of the changelog folders",
+				Required: false,
 			},
 			&cli.StringFlag{
-				Name:     "github-api-repo",
-				Usage:    "The repo name that is used to compose the GitHub URL to retrieve data. (OWNER/REPO)",
-				Required: true,
-			},
-			&cli.BoolFlag{
-				Name:     "with-jiras",
-				Usage:    "Display Jira links",
+				Name:     "to",
+				Usage:    "The git ref the entries are read at with --from (defaults to HEAD)",
 				Required: false,
 			},
-			&cli.StringFlag{
-				Name:     "source-branch",
-				Usage:    "The minor branch the fix-release branch was cut from (origin/next/3.14.x.x). When set, each entry is attributed to the PR of its commit on this branch (the release-line/backport PR) instead of the upstream/master or sync PR.",
+			&cli.BoolFlag{
+				Name:     "infer",
+				Usage:    "Infer the type and scope of entries that omit them from the PR title (fix(plugins/acl): ...) and the paths it changed",
 				Required: false,
 			},
-		},
+		),
 		Action: func(c *cli.Context) error {
-			githubToken := os.Getenv("GITHUB_TOKEN")
-			if githubToken == "" {
-				return errors.New("environment variable GITHUB_TOKEN is required")
+			setup, err := setupGenerate(c)
+			if err != nil {
+				return err
 			}
 
-			httpClient := &http.Client{}
-			if debug {
-				httpClient.Transport = &LoggingTransport{
-					Transport: http.DefaultTransport,
+			options := &setup.options
+			if options.ToRef != "" && options.FromRef == "" {
+				return errors.New("--to requires --from")
+			}
+			if options.FromRef != "" && options.ToRef == "" {
+				options.ToRef = "HEAD"
+			}
+			for _, ref := range []string{options.FromRef, options.ToRef} {
+				if ref != "" && !utils.RefExists(options.RepoPath, ref) {
+					return fmt.Errorf("git ref %q not found in %s", ref, options.RepoPath)
 				}
 			}
-			client = github.NewClient(httpClient).WithAuthToken(githubToken)
 
-			parts := strings.Split(c.String("github-api-repo"), "/")
+			generator := setup.generator()
+			defer generator.Close()
 
-			repoPath := c.String("repo-path")
-			sourceBranch := c.String("source-branch")
-			if sourceBranch != "" && !utils.RefExists(repoPath, sourceBranch) {
-				Error("source branch %q not found in %s; cherry-pick source attribution disabled\n", sourceBranch, repoPath)
-				sourceBranch = ""
+			report := newActionsReport(c)
+			if report == nil {
+				return generator.Generate(c.App.Writer, c.App.ErrWriter)
 			}
 
-			options = GenerateCmdOptions{
-				RepoPath:        repoPath,
-				ChangelogPaths:  c.StringSlice("changelog-paths"),
-				Title:           c.String("title"),
-				GithubApiOwner:  parts[0],
-				GithubApiRepo:   parts[1],
-				GithubIssueRepo: c.String("github-issue-repo"),
-				WithJiras:       c.Bool("with-jiras"),
-				SourceBranch:    sourceBranch,
+			data, failures, err := generator.Collect()
+			generator.WriteSummary(c.App.ErrWriter, failures)
+			report.annotate(generator, failures)
+			if err != nil {
+				return err
 			}
 
-			return Generate()
+			var notes bytes.Buffer
+			if err := generator.Render(&notes, data); err != nil {
+				return err
+			}
+			if err := report.writeStepSummary(generator, failures, notes.String()); err != nil {
+				return err
+			}
+			_, err = c.App.Writer.Write(notes.Bytes())
+			return err
 		},
 	}
 
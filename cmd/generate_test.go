@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// runGit runs git in dir with a fixed identity and fixed dates, so that the
+// commits of the fixture repository, and the recorded GitHub requests naming
+// them, are the same on every run.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_AUTHOR_DATE=2024-01-01T00:00:00Z",
+		"GIT_COMMITTER_NAME=test",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_COMMITTER_DATE=2024-01-01T00:00:00Z",
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return string(out)
+}
+
+// commitFile writes path (creating parent dirs) with content, commits it, and
+// returns the new SHA.
+func commitFile(t *testing.T, dir, path, content, msg string) string {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", path)
+	runGit(t, dir, "commit", "-q", "--no-gpg-sign", "-m", msg)
+	return strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+}
+
+// newFixtureRepo creates the repository the responses under
+// testdata/github were recorded for: an entry whose PR GitHub lists for its
+// commit, one whose PR is only referenced by its commit message, and one
+// without a PR.
+func newFixtureRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	commitFile(t, dir, "README.md", "kong\n", "init")
+	commitFile(t, dir, "changelog/unreleased/kong/fix-crash.yml",
+		"message: Fixed a crash when the upstream closed the connection early.\ntype: bugfix\nscope: Core\n",
+		"fix(core): crash on early close (#10)")
+	commitFile(t, dir, "changelog/unreleased/kong/acl-groups.yml",
+		"message: \"**acl**: Added support for nested groups.\"\ntype: feature\nscope: Plugin\n",
+		"feat(acl): nested groups (#11)")
+	commitFile(t, dir, "changelog/unreleased/kong/orphan.yml",
+		"message: Pushed without a PR.\ntype: bugfix\n",
+		"direct push")
+	return dir
+}
+
+// runApp runs the changelog command line with args and returns what it wrote
+// to its output.
+func runApp(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	app := New()
+	app.Writer = &out
+	err := app.Run(append([]string{"changelog"}, args...))
+	return out.String(), err
+}
+
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Fatalf("output differs from %s:\n%s", path, got)
+	}
+}
+
+func TestGenerateReplay(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+	dir := newFixtureRepo(t)
+
+	out, err := runApp(t, "generate",
+		"--repo-path", dir,
+		"--changelog-paths", "changelog/unreleased/kong",
+		"--github-issue-repo", "Kong/kong",
+		"--github-api-repo", "Kong/kong",
+		"--title", "Kong",
+		"--with-contributors",
+		"--plugin-headings", "each",
+		"--replay-http", filepath.Join("testdata", "github"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "generate.md", out)
+}
+
+func TestGenerateReplayMissingRecording(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+	dir := newFixtureRepo(t)
+
+	_, err := runApp(t, "generate",
+		"--repo-path", dir,
+		"--changelog-paths", "changelog/unreleased/kong",
+		"--github-issue-repo", "Kong/kong",
+		"--github-api-repo", "Kong/other",
+		"--title", "Kong",
+		"--replay-http", filepath.Join("testdata", "github"),
+	)
+	if err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("err = %v, want a missing recording", err)
+	}
+}
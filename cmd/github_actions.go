@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/urfave/cli/v2"
+)
+
+// actionsReport reports a run of generate or release to GitHub Actions: the
+// skipped and flagged entries as annotations on their files, and the notes
+// with a table of those entries in the job summary.
+type actionsReport struct {
+	// out receives the workflow commands. The runner reads them from stderr
+	// as well as stdout, which is left to the notes.
+	out io.Writer
+	// workspace is the checkout the annotated files are relative to.
+	workspace string
+	// summaryPath is the job summary file, or "" to not write one.
+	summaryPath string
+}
+
+// githubActionsFlag returns the flag enabling the report, shared by the
+// commands that have entries to annotate.
+func githubActionsFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:     "github-actions",
+		Usage:    "Annotate the entry files with the problems found, and write the notes to $GITHUB_STEP_SUMMARY; set by default in GitHub Actions workflows",
+		EnvVars:  []string{"GITHUB_ACTIONS"},
+		Required: false,
+	}
+}
+
+// newActionsReport returns the report of the run, or nil when it does not run
+// in a GitHub Actions workflow.
+func newActionsReport(c *cli.Context) *actionsReport {
+	if !c.Bool("github-actions") {
+		return nil
+	}
+
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if workspace == "" {
+		workspace, _ = os.Getwd()
+	}
+	return &actionsReport{
+		out:         c.App.ErrWriter,
+		workspace:   workspace,
+		summaryPath: os.Getenv("GITHUB_STEP_SUMMARY"),
+	}
+}
+
+// escapeData escapes a workflow command message.
+func escapeData(value string) string {
+	value = strings.ReplaceAll(value, "%", "%25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	return strings.ReplaceAll(value, "\n", "%0A")
+}
+
+// escapeProperty escapes a workflow command property value.
+func escapeProperty(value string) string {
+	value = escapeData(value)
+	value = strings.ReplaceAll(value, ":", "%3A")
+	return strings.ReplaceAll(value, ",", "%2C")
+}
+
+// relativePath returns file relative to the workspace, as annotations expect,
+// or unchanged when it lies outside of it.
+func (r *actionsReport) relativePath(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(r.workspace, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (r *actionsReport) annotation(level, file, title, message string) {
+	fmt.Fprintf(r.out, "::%s file=%s,title=%s::%s\n", level, escapeProperty(r.relativePath(file)), escapeProperty(title), escapeData(message))
+}
+
+// annotateDiagnostics writes an error annotation titled title at the line
+// and column of each diagnostic.
+func (r *actionsReport) annotateDiagnostics(title string, diagnostics []changelog.Diagnostic) {
+	for _, d := range diagnostics {
+		message := d.Message
+		if d.Suggestion != "" {
+			message += fmt.Sprintf(" (did you mean %q?)", d.Suggestion)
+		}
+		if d.Rule != "" {
+			message += fmt.Sprintf(" [%s]", d.Rule)
+		}
+		fmt.Fprintf(r.out, "::error file=%s,line=%d,col=%d,title=%s::%s\n", escapeProperty(r.relativePath(d.File)), d.Line, d.Column, escapeProperty(title), escapeData(message))
+	}
+}
+
+// failureReason returns the error of failure, naming its commit unless the
+// error already does.
+func failureReason(failure changelog.EntryProcessingFailure) string {
+	reason := failure.Err.Error()
+	if failure.CommitSHA == "" || strings.Contains(reason, failure.CommitSHA) {
+		return reason
+	}
+	return fmt.Sprintf("%s (commit %s)", reason, failure.CommitSHA)
+}
+
+// annotate writes an error annotation for each failure (one per diagnostic
+// of an invalid entry), a warning for each reverted or duplicate entry and a
+// notice for each entry already shipped.
+func (r *actionsReport) annotate(generator *changelog.Generator, failures []changelog.EntryProcessingFailure) {
+	for _, failure := range failures {
+		var invalid *changelog.ValidationError
+		if errors.As(failure.Err, &invalid) {
+			r.annotateDiagnostics("Invalid changelog entry", invalid.Diagnostics)
+			continue
+		}
+		r.annotation("error", failure.FileName, "Skipped changelog entry", failureReason(failure))
+	}
+	for _, reverted := range generator.Reverted() {
+		r.annotation("warning", reverted.FileName, "Reverted changelog entry", reverted.Reason)
+	}
+	for _, duplicate := range generator.Duplicates() {
+		r.annotation("warning", duplicate.FileName, "Duplicate changelog entry", duplicate.Reason)
+	}
+	for _, shipped := range generator.Shipped() {
+		r.annotation("notice", shipped.FileName, "Changelog entry already shipped", shipped.Reason)
+	}
+}
+
+// writeStepSummary appends notes and the table of the skipped and flagged
+// entries to the job summary.
+func (r *actionsReport) writeStepSummary(generator *changelog.Generator, failures []changelog.EntryProcessingFailure, notes string) error {
+	if r.summaryPath == "" {
+		return nil
+	}
+
+	var summary bytes.Buffer
+	if notes != "" {
+		summary.WriteString(strings.TrimSpace(notes) + "\n\n")
+	}
+
+	rows := make([]string, 0)
+	for _, failure := range failures {
+		rows = append(rows, r.summaryRow(failure.FileName, "skipped", failureReason(failure)))
+	}
+	for _, reverted := range generator.Reverted() {
+		rows = append(rows, r.summaryRow(reverted.FileName, "reverted", reverted.Reason))
+	}
+	for _, duplicate := range generator.Duplicates() {
+		rows = append(rows, r.summaryRow(duplicate.FileName, "duplicate", duplicate.Reason))
+	}
+	for _, shipped := range generator.Shipped() {
+		rows = append(rows, r.summaryRow(shipped.FileName, "already shipped", shipped.Reason))
+	}
+	if len(rows) > 0 {
+		summary.WriteString("### Skipped and flagged changelog entries\n\n")
+		summary.WriteString("| Entry | Status | Reason |\n| --- | --- | --- |\n")
+		summary.WriteString(strings.Join(rows, ""))
+		summary.WriteString("\n")
+	}
+
+	file, err := os.OpenFile(r.summaryPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the job summary: %v", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(summary.Bytes())
+	return err
+}
+
+func (r *actionsReport) summaryRow(file, status, reason string) string {
+	return fmt.Sprintf("| `%s` | %s | %s |\n", r.relativePath(file), status, markdownCell(reason))
+}
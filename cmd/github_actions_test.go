@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateReportsToGitHubActions(t *testing.T) {
+	dir := newFixtureRepo(t)
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_WORKSPACE", dir)
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+
+	var out, errOut bytes.Buffer
+	app := New()
+	app.Writer = &out
+	app.ErrWriter = &errOut
+	err := app.Run([]string{"changelog", "--quiet", "generate",
+		"--repo-path", dir,
+		"--changelog-paths", "changelog/unreleased/kong",
+		"--github-issue-repo", "Kong/kong",
+		"--github-api-repo", "Kong/kong",
+		"--title", "Kong",
+		"--replay-http", filepath.Join("testdata", "github"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	annotation := "::error file=changelog/unreleased/kong/orphan.yml,title=Skipped changelog entry::"
+	if !strings.Contains(errOut.String(), annotation) {
+		t.Fatalf("stderr has no %q:\n%s", annotation, errOut.String())
+	}
+	if strings.Contains(out.String(), "::error") {
+		t.Fatalf("annotations written to the notes:\n%s", out.String())
+	}
+
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"## Kong",
+		"Fixed a crash when the upstream closed the connection early.",
+		"| `changelog/unreleased/kong/orphan.yml` | skipped | failed to fetch commit ctx: no merged PR found for commit 7ae9113c2453ac0ac8bc8560111eddea3789c3f3 |",
+	} {
+		if !strings.Contains(string(summary), want) {
+			t.Fatalf("job summary has no %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestEscapeWorkflowCommand(t *testing.T) {
+	if got, want := escapeData("50% done\nnext"), "50%25 done%0Anext"; got != want {
+		t.Fatalf("escapeData() = %q, want %q", got, want)
+	}
+	if got, want := escapeProperty("a,b:c"), "a%2Cb%3Ac"; got != want {
+		t.Fatalf("escapeProperty() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/urfave/cli/v2"
+)
+
+func newLintCmd() *cli.Command {
+	cmd := &cli.Command{
+		Name:        "lint",
+		Usage:       "changelog lint [options] <file or folder>...",
+		Description: "The lint command checks the messages of changelog entry files against the style rules (past-tense, sentence-case, terminal-period, no-raw-urls, config-key-backticks, max-line-length) turned on in the lint config; a \"# lint-disable <rule>\" comment in an entry file suppresses a rule for it",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "config",
+				Usage:    "The changelog config file turning the lint rules on or off",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "format",
+				Usage:    "The output format (text, json)",
+				Value:    formatText,
+				Required: false,
+			},
+			githubActionsFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("no changelog files given, usage: %s", c.Command.Usage)
+			}
+
+			format := c.String("format")
+			if format != formatText && format != formatJSON {
+				return fmt.Errorf("unknown format %q, must be one of: %s, %s", format, formatText, formatJSON)
+			}
+
+			config, err := changelog.LoadConfig(c.String("config"))
+			if err != nil {
+				return err
+			}
+			linter := changelog.NewLinter(config)
+
+			files, err := entryFiles(c.Args().Slice())
+			if err != nil {
+				return err
+			}
+
+			diagnostics := make([]changelog.Diagnostic, 0)
+			flagged := 0
+			for _, file := range files {
+				content, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				fileDiagnostics := linter.LintFile(file, content)
+				if len(fileDiagnostics) > 0 {
+					flagged++
+				}
+				logger.Debug("linted changelog file", "file", file, "diagnostics", len(fileDiagnostics))
+				diagnostics = append(diagnostics, fileDiagnostics...)
+			}
+
+			if report := newActionsReport(c); report != nil {
+				report.annotateDiagnostics("Changelog style", diagnostics)
+			}
+
+			if err := writeDiagnostics(c.App.Writer, format, diagnostics); err != nil {
+				return err
+			}
+
+			if flagged > 0 {
+				return fmt.Errorf("changelog entries not following the style rules: %d of %d", flagged, len(files))
+			}
+			logger.Info("all changelog entries follow the style rules", "count", len(files))
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kong/changelog/pkg/changelog"
+)
+
+func TestLintJSON(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yml")
+	entry := filepath.Join(dir, "entry.yml")
+	for path, content := range map[string]string{
+		config: "lint:\n  rules:\n    terminal-period: false\n",
+		entry:  "message: Fix the nginx_http_foo option\ntype: bugfix\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := runApp(t, "--quiet", "lint", "--config", config, "--format", "json", entry)
+	if err == nil {
+		t.Fatal("lint succeeded, want the style problems reported")
+	}
+
+	var diagnostics []changelog.Diagnostic
+	if err := json.Unmarshal([]byte(out), &diagnostics); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	rules := make([]string, 0)
+	for _, d := range diagnostics {
+		rules = append(rules, d.Rule)
+	}
+	if len(rules) != 2 || rules[0] != "past-tense" || rules[1] != "config-key-backticks" {
+		t.Fatalf("lint reported the rules %v, want past-tense and config-key-backticks", rules)
+	}
+	if diagnostics[0].Suggestion != "Fixed" {
+		t.Fatalf("past-tense suggestion = %q, want Fixed", diagnostics[0].Suggestion)
+	}
+}
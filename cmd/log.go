@@ -2,21 +2,41 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 )
 
-func Debug(format string, v ...any) {
-	if !debug {
-		return
-	}
-	log.Printf(format, v...)
-}
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
 
-func Info(format string, v ...any) {
-	log.Printf(format, v...)
-}
+// logger is the logger of the commands, set up from the global flags by
+// setupLogger before a command runs.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
 
-func Error(format string, v ...any) {
-	fmt.Fprintf(os.Stderr, format, v...)
+// setupLogger sets logger to write to w at level (debug, info, warn or error)
+// in format (text or json). Quiet only lets errors through, leaving the
+// summaries the commands print at the end as the only output besides theirs.
+func setupLogger(w io.Writer, level, format string, quiet bool) error {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log-level %q, must be one of: debug, info, warn, error", level)
+	}
+	if quiet {
+		logLevel = slog.LevelError
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel}
+	switch strings.ToLower(format) {
+	case logFormatText:
+		logger = slog.New(slog.NewTextHandler(w, options))
+	case logFormatJSON:
+		logger = slog.New(slog.NewJSONHandler(w, options))
+	default:
+		return fmt.Errorf("unknown log-format %q, must be one of: %s, %s", format, logFormatText, logFormatJSON)
+	}
+	return nil
 }
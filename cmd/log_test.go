@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSetupLogger(t *testing.T) {
+	var out bytes.Buffer
+	if err := setupLogger(&out, "warn", logFormatJSON, false); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("processing changelog file", "file", "a.yml")
+	logger.Warn("skipping changelog entry", "file", "b.yml", "sha", "abc", "pr", 1)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("logged %q, want only the warning", out.String())
+	}
+	record := map[string]any{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["file"] != "b.yml" || record["sha"] != "abc" || record["pr"] != float64(1) {
+		t.Fatalf("logged %v, want the entry fields", record)
+	}
+
+	out.Reset()
+	if err := setupLogger(&out, "debug", logFormatText, true); err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("skipping changelog entry", "file", "b.yml")
+	if out.Len() != 0 {
+		t.Fatalf("quiet logged %q", out.String())
+	}
+
+	for _, args := range [][2]string{{"verbose", logFormatText}, {"info", "xml"}} {
+		if err := setupLogger(&out, args[0], args[1], false); err == nil {
+			t.Fatalf("setupLogger(%q, %q) accepted", args[0], args[1])
+		}
+	}
+}
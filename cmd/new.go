@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/Kong/changelog/utils"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// newEntryFile is the on-disk layout of a scaffolded entry, in the key order
+// of the README and without the keys left empty.
+type newEntryFile struct {
+	Message  string   `yaml:"message"`
+	Type     string   `yaml:"type"`
+	Scope    string   `yaml:"scope,omitempty"`
+	Prs      []int    `yaml:"prs,omitempty"`
+	Jiras    []string `yaml:"jiras,omitempty"`
+	Editions []string `yaml:"editions,omitempty"`
+	Products []string `yaml:"products,omitempty"`
+}
+
+var nonSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// entryFileName derives a file name from the first few words of message,
+// e.g. "Bumped OpenSSL to 3.2" becomes "bumped_openssl_to_3_2.yml".
+func entryFileName(message string) string {
+	words := strings.Fields(strings.ToLower(message))
+	if len(words) > 6 {
+		words = words[:6]
+	}
+
+	slug := strings.Trim(nonSlugPattern.ReplaceAllString(strings.Join(words, " "), "_"), "_")
+	if slug == "" {
+		slug = "changelog"
+	}
+	return slug + ".yml"
+}
+
+// defaultBaseBranches are the branches tried, in order, as the base of the
+// current branch when --base is not given.
+var defaultBaseBranches = []string{"origin/master", "origin/main", "master", "main"}
+
+// branchReferences returns the PR numbers and Jira tickets referenced by the
+// current branch name and by the messages of its own commits, those reachable
+// from HEAD but not from base. The commits of base, such as the squash-merged
+// "(#1234)" commit a fresh branch starts from, belong to other changes and
+// are left out; so are all commits when base cannot be resolved.
+func branchReferences(repoPath, base string) ([]int, []string) {
+	text := utils.CurrentBranch(repoPath)
+	if base != "" && utils.RefExists(repoPath, base) {
+		messages, err := utils.CommitMessagesBetween(repoPath, base, "HEAD")
+		if err != nil {
+			logger.Debug("not reading the branch commits for references", "base", base, "error", err)
+		}
+		for _, message := range messages {
+			text += "\n" + message
+		}
+	} else {
+		logger.Debug("base branch not found; only reading references from the branch name", "base", base)
+	}
+
+	prs := changelog.PullRequestRefs(text)
+
+	jiras := make([]string, 0)
+	for _, jira := range utils.MatchJiras(text) {
+		if !contains(jiras, jira) {
+			jiras = append(jiras, jira)
+		}
+	}
+
+	return prs, jiras
+}
+
+// baseBranch returns the --base flag, or else the first of
+// defaultBaseBranches found in repoPath, or "" when there is none.
+func baseBranch(c *cli.Context, repoPath string) string {
+	if c.IsSet("base") {
+		return c.String("base")
+	}
+	for _, branch := range defaultBaseBranches {
+		if utils.RefExists(repoPath, branch) {
+			return branch
+		}
+	}
+	return ""
+}
+
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	eof bool
+}
+
+// ask prints question and returns the trimmed answer, or def when the answer
+// is empty or the input is exhausted.
+func (p *prompter) ask(question, def string) (string, error) {
+	if p.eof {
+		return def, nil
+	}
+
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	answer, err := p.in.ReadString('\n')
+	if err == io.EOF {
+		p.eof = true
+		fmt.Fprintln(p.out)
+	} else if err != nil {
+		return "", err
+	}
+
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// choose asks for one of choices, accepted either by value or by its number
+// in the printed list. An empty answer is allowed when optional is set.
+func (p *prompter) choose(question string, choices []string, optional bool) (string, error) {
+	for i, choice := range choices {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, choice)
+	}
+
+	for {
+		answer, err := p.ask(question, "")
+		if err != nil {
+			return "", err
+		}
+		if answer == "" && (optional || p.eof) {
+			return "", nil
+		}
+		if no, err := strconv.Atoi(answer); err == nil && no >= 1 && no <= len(choices) {
+			return choices[no-1], nil
+		}
+		if contains(choices, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(p.out, "%q is not one of the listed values\n", answer)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		parts = append(parts, strconv.Itoa(v))
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitList(value string) []string {
+	list := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+func parseInts(value string) ([]int, error) {
+	list := make([]int, 0)
+	for _, part := range splitList(strings.ReplaceAll(value, "#", "")) {
+		no, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PR number %q", part)
+		}
+		list = append(list, no)
+	}
+	return list, nil
+}
+
+// fillNewEntry completes the entry from the answers to the prompts for the
+// fields the flags left unset, offering the types and scopes of config.
+func fillNewEntry(p *prompter, config changelog.Config, entry *newEntryFile, prs []int, jiras []string) error {
+	var err error
+	if entry.Message == "" {
+		if entry.Message, err = p.ask("Message", ""); err != nil {
+			return err
+		}
+	}
+	if entry.Type == "" {
+		if entry.Type, err = p.choose("Type", config.Types, false); err != nil {
+			return err
+		}
+	}
+	if entry.Scope == "" {
+		if entry.Scope, err = p.choose("Scope (optional)", config.Scopes, true); err != nil {
+			return err
+		}
+	}
+	if entry.Prs == nil {
+		answer, err := p.ask("PRs (comma-separated, optional)", joinInts(prs))
+		if err != nil {
+			return err
+		}
+		if entry.Prs, err = parseInts(answer); err != nil {
+			return err
+		}
+	}
+	if entry.Jiras == nil {
+		answer, err := p.ask("Jiras (comma-separated, optional)", strings.Join(jiras, ","))
+		if err != nil {
+			return err
+		}
+		entry.Jiras = splitList(answer)
+	}
+	return nil
+}
+
+func newNewCmd() *cli.Command {
+	cmd := &cli.Command{
+		Name:        "new",
+		Description: "The new command scaffolds a changelog entry file, prompting for the fields not given as flags",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "repo-path",
+				Usage:    "The repository path (/path/to/your/repository)",
+				Value:    ".",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "changelog-path",
+				Usage:    "The changelog folder relative path the entry is created in",
+				Value:    "changelog/unreleased/kong",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "config",
+				Usage:    "The changelog config file declaring the allowed types, scopes, editions and products",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "name",
+				Usage:    "The entry file name, without extension (derived from the message when omitted)",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "message",
+				Usage:    "The entry message",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "type",
+				Usage:    "The entry type (bugfix)",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "scope",
+				Usage:    "The entry scope (Core)",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "base",
+				Usage:    "The branch the current branch was cut from; the PRs and Jira tickets are only pre-filled from the commits not on it (defaults to the first of origin/master, origin/main, master and main found)",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "prs",
+				Usage:    "Comma-separated PR numbers (defaults to the PRs referenced by the current branch and its commits not on --base)",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "jiras",
+				Usage:    "Comma-separated Jira tickets (defaults to the tickets referenced by the current branch and its commits not on --base)",
+				Required: false,
+			},
+			&cli.StringSliceFlag{
+				Name:     "edition",
+				Usage:    "An edition the change applies to; repeat for several",
+				Required: false,
+			},
+			&cli.StringSliceFlag{
+				Name:     "product",
+				Usage:    "A product the change applies to; repeat for several",
+				Required: false,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			config, err := changelog.LoadConfig(c.String("config"))
+			if err != nil {
+				return err
+			}
+
+			repoPath := c.String("repo-path")
+			prs, jiras := branchReferences(repoPath, baseBranch(c, repoPath))
+
+			entry := &newEntryFile{
+				Message:  c.String("message"),
+				Type:     c.String("type"),
+				Scope:    c.String("scope"),
+				Editions: c.StringSlice("edition"),
+				Products: c.StringSlice("product"),
+			}
+			if c.IsSet("prs") {
+				if entry.Prs, err = parseInts(c.String("prs")); err != nil {
+					return err
+				}
+			}
+			if c.IsSet("jiras") {
+				entry.Jiras = splitList(c.String("jiras"))
+			}
+
+			if isTerminal(os.Stdin) {
+				p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+				if err := fillNewEntry(p, config, entry, prs, jiras); err != nil {
+					return err
+				}
+			} else {
+				if entry.Prs == nil {
+					entry.Prs = prs
+				}
+				if entry.Jiras == nil {
+					entry.Jiras = jiras
+				}
+			}
+
+			name := entryFileName(entry.Message)
+			if c.String("name") != "" {
+				name = strings.TrimSuffix(c.String("name"), ".yml") + ".yml"
+			}
+			dir := filepath.Join(repoPath, c.String("changelog-path"))
+			filePath := filepath.Join(dir, name)
+
+			content, err := yaml.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if diagnostics := config.ValidateFile(filePath, content); len(diagnostics) > 0 {
+				return fmt.Errorf("invalid changelog entry:\n%v", &changelog.ValidationError{Diagnostics: diagnostics})
+			}
+
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+
+			file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				if errors.Is(err, os.ErrExist) {
+					return fmt.Errorf("changelog file %s already exists, pick another --name", filePath)
+				}
+				return err
+			}
+			defer file.Close()
+
+			if _, err := file.Write(content); err != nil {
+				return err
+			}
+
+			logger.Info("created changelog file", "file", filePath)
+			return nil
+		},
+	}
+
+	return cmd
+}
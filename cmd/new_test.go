@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Kong/changelog/pkg/changelog"
+)
+
+func TestEntryFileName(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: "Bumped OpenSSL to 3.2", want: "bumped_openssl_to_3_2.yml"},
+		{message: "**acl**: Fixed the groups header when the consumer has no group", want: "acl_fixed_the_groups_header_when.yml"},
+		{message: "  ", want: "changelog.yml"},
+	}
+
+	for _, tc := range tests {
+		if got := entryFileName(tc.message); got != tc.want {
+			t.Errorf("entryFileName(%q) = %q, want %q", tc.message, got, tc.want)
+		}
+	}
+}
+
+func TestParseInts(t *testing.T) {
+	got, err := parseInts("#12, 13,,")
+	if err != nil || !reflect.DeepEqual(got, []int{12, 13}) {
+		t.Fatalf("parseInts() = %v, %v, want [12 13]", got, err)
+	}
+	if _, err := parseInts("12, abc"); err == nil || err.Error() != `invalid PR number "abc"` {
+		t.Fatalf("parseInts(bad token) error = %v", err)
+	}
+}
+
+func TestFillNewEntry(t *testing.T) {
+	// The message, type by number, no scope, the pre-filled PRs and other
+	// Jiras; the scope prompt re-asks after a value that is not listed.
+	input := "Fixed a crash.\n2\nNotAScope\n\n\nFTI-1, FTI-2\n"
+	var out bytes.Buffer
+	p := &prompter{in: bufio.NewReader(strings.NewReader(input)), out: &out}
+
+	entry := &newEntryFile{}
+	if err := fillNewEntry(p, changelog.DefaultConfig(), entry, []int{12}, []string{"FTI-9"}); err != nil {
+		t.Fatal(err)
+	}
+	want := &newEntryFile{
+		Message: "Fixed a crash.",
+		Type:    "bugfix",
+		Prs:     []int{12},
+		Jiras:   []string{"FTI-1", "FTI-2"},
+	}
+	if !reflect.DeepEqual(entry, want) {
+		t.Fatalf("fillNewEntry() = %+v, want %+v", entry, want)
+	}
+	if !strings.Contains(out.String(), `"NotAScope" is not one of the listed values`) {
+		t.Fatalf("prompts did not reject the unknown scope:\n%s", out.String())
+	}
+
+	// Fields given as flags are not asked for, and the defaults apply once
+	// the input is exhausted.
+	p = &prompter{in: bufio.NewReader(strings.NewReader("")), out: &out}
+	entry = &newEntryFile{Message: "Added a thing.", Type: "feature"}
+	if err := fillNewEntry(p, changelog.DefaultConfig(), entry, []int{7}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Scope != "" || !reflect.DeepEqual(entry.Prs, []int{7}) || len(entry.Jiras) != 0 {
+		t.Fatalf("fillNewEntry() at EOF = %+v", entry)
+	}
+}
+
+func TestNewPrefillsOnlyBranchCommits(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "master")
+	commitFile(t, dir, "README.md", "kong\n", "feat(core): someone else's change (#10)\n\nFTI-10")
+	runGit(t, dir, "checkout", "-q", "-b", "fix/balancer")
+
+	readEntry := func(name string) string {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(dir, "changelog/unreleased/kong", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	// HEAD is the base branch's squash-merged commit: nothing to pre-fill.
+	if _, err := runApp(t, "--quiet", "new", "--repo-path", dir, "--base", "master", "--name", "fresh", "--message", "Fixed a crash.", "--type", "bugfix"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readEntry("fresh.yml"), "message: Fixed a crash.\ntype: bugfix\n"; got != want {
+		t.Fatalf("entry on a fresh branch =\n%s\nwant\n%s", got, want)
+	}
+
+	commitFile(t, dir, "balancer.lua", "fix\n", "fix(balancer): crash (#12)\n\nFTI-12")
+	if _, err := runApp(t, "--quiet", "new", "--repo-path", dir, "--base", "master", "--name", "fixed", "--message", "Fixed a crash.", "--type", "bugfix"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readEntry("fixed.yml"), "message: Fixed a crash.\ntype: bugfix\nprs:\n    - 12\njiras:\n    - FTI-12\n"; got != want {
+		t.Fatalf("entry after a branch commit =\n%s\nwant\n%s", got, want)
+	}
+}
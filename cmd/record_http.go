@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// recordedResponse is the file a RecordingTransport writes for each request
+// and a ReplayTransport answers it from.
+type recordedResponse struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+var nonFileNamePattern = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// recordingPath returns the file the response to request is recorded in under
+// dir, named after its method, path and query, e.g.
+// GET_repos_Kong_kong_commits_<sha>_pulls.json.
+func recordingPath(dir string, request *http.Request) string {
+	name := request.Method + " " + request.URL.Path
+	if request.URL.RawQuery != "" {
+		name += " " + request.URL.RawQuery
+	}
+	return filepath.Join(dir, nonFileNamePattern.ReplaceAllString(name, "_")+".json")
+}
+
+// RecordingTransport writes every response to a file under Dir, for
+// ReplayTransport to answer the same requests later without network. Only
+// the status, content type and body are kept, so that recordings hold no
+// credentials and do not change from one run to the next.
+type RecordingTransport struct {
+	Transport http.RoundTripper
+	Dir       string
+}
+
+func (t *RecordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.Transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorded := recordedResponse{
+		Method:      request.Method,
+		URL:         request.URL.String(),
+		Status:      response.StatusCode,
+		ContentType: response.Header.Get("Content-Type"),
+		Body:        string(body),
+	}
+	content, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := recordingPath(t.Dir, request)
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	logger.Debug("recorded github response", "method", request.Method, "url", request.URL.String(), "file", path)
+
+	return response, nil
+}
+
+// ReplayTransport answers requests from the files a RecordingTransport wrote
+// under Dir, and fails those that were not recorded.
+type ReplayTransport struct {
+	Dir string
+}
+
+func (t *ReplayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	path := recordingPath(t.Dir, request)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recorded response for %s %s in %s", request.Method, request.URL, t.Dir)
+		}
+		return nil, err
+	}
+
+	recorded := recordedResponse{}
+	if err := json.Unmarshal(content, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to read recorded response %s: %v", path, err)
+	}
+
+	header := make(http.Header)
+	if recorded.ContentType != "" {
+		header.Set("Content-Type", recorded.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       request,
+	}, nil
+}
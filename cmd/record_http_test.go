@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/Kong/kong/pulls/1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-GitHub-Request-Id", "varies")
+		_, _ = io.WriteString(w, `{"number":1}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recording := &http.Client{Transport: &RecordingTransport{Transport: http.DefaultTransport, Dir: dir}}
+	replaying := &http.Client{Transport: &ReplayTransport{Dir: dir}}
+
+	for _, path := range []string{"/repos/Kong/kong/pulls/1", "/repos/Kong/kong/pulls/2?page=2"} {
+		recorded, err := recording.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recordedBody, _ := io.ReadAll(recorded.Body)
+		recorded.Body.Close()
+
+		replayed, err := replaying.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replayedBody, _ := io.ReadAll(replayed.Body)
+		replayed.Body.Close()
+
+		if replayed.StatusCode != recorded.StatusCode || string(replayedBody) != string(recordedBody) {
+			t.Fatalf("%s replayed %d %q, recorded %d %q", path, replayed.StatusCode, replayedBody, recorded.StatusCode, recordedBody)
+		}
+		if got, want := replayed.Header.Get("Content-Type"), recorded.Header.Get("Content-Type"); got != want {
+			t.Fatalf("%s replayed Content-Type %q, want %q", path, got, want)
+		}
+		if replayed.Header.Get("X-GitHub-Request-Id") != "" {
+			t.Fatalf("%s replayed headers that vary between runs", path)
+		}
+	}
+
+	if _, err := replaying.Get(server.URL + "/repos/Kong/kong/pulls/3"); err == nil {
+		t.Fatal("replaying an unrecorded request succeeded")
+	}
+}
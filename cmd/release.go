@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/Kong/changelog/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func newReleaseCmd() *cli.Command {
+	cmd := &cli.Command{
+		Name:        "release",
+		Description: "The release command moves the unreleased entries into a version folder and prepends their notes to CHANGELOG.md",
+		Flags: append(generateFlags(),
+			&cli.StringFlag{
+				Name:     "version",
+				Usage:    "The version being released; replaces the unreleased folder of each changelog path (3.14.0.9)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "title",
+				Usage:    "The title name (defaults to the version)",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "changelog-file",
+				Usage:    "The changelog document the notes are added to, relative to the repository path",
+				Value:    "CHANGELOG.md",
+				Required: false,
+			},
+			&cli.BoolFlag{
+				Name:     "dry-run",
+				Usage:    "Print the notes and the planned moves without changing anything",
+				Required: false,
+			},
+		),
+		Action: func(c *cli.Context) error {
+			setup, err := setupGenerate(c)
+			if err != nil {
+				return err
+			}
+
+			version := c.String("version")
+			options := &setup.options
+			options.Strict = true
+			if options.Title == "" {
+				options.Title = version
+			}
+
+			moves, err := changelog.ReleaseMoves(options.RepoPath, options.ChangelogPaths, version)
+			if err != nil {
+				return err
+			}
+			if len(moves) == 0 {
+				return fmt.Errorf("no changelog entries to release in %s", strings.Join(options.ChangelogPaths, ", "))
+			}
+
+			generator := setup.generator()
+			defer generator.Close()
+			data, failures, err := generator.Collect()
+			generator.WriteSummary(c.App.ErrWriter, failures)
+			report := newActionsReport(c)
+			if report != nil {
+				report.annotate(generator, failures)
+			}
+			if err != nil {
+				return err
+			}
+			if len(failures) > 0 {
+				if report != nil {
+					if err := report.writeStepSummary(generator, failures, ""); err != nil {
+						return err
+					}
+				}
+				return fmt.Errorf("refusing to release %s: %d changelog entries failed validation or attribution", version, len(failures))
+			}
+
+			moves, left := changelog.SplitReleaseMoves(moves, generator.Collected())
+			for _, move := range left {
+				logger.Warn("leaving changelog file unreleased, it is not in the notes", "file", move.From)
+			}
+			if len(moves) == 0 {
+				return fmt.Errorf("no changelog entries to release in %s", strings.Join(options.ChangelogPaths, ", "))
+			}
+
+			var notes bytes.Buffer
+			if err := generator.Render(&notes, data); err != nil {
+				return err
+			}
+			if report != nil {
+				if err := report.writeStepSummary(generator, failures, notes.String()); err != nil {
+					return err
+				}
+			}
+
+			if c.Bool("dry-run") {
+				for _, move := range moves {
+					logger.Info("would move changelog file", "from", move.From, "to", move.To)
+				}
+				fmt.Fprint(c.App.Writer, notes.String())
+				return nil
+			}
+
+			if err := changelog.ApplyReleaseMoves(options.RepoPath, moves); err != nil {
+				return err
+			}
+			for _, move := range moves {
+				logger.Debug("moved changelog file", "from", move.From, "to", move.To)
+			}
+
+			changelogFile := filepath.Join(options.RepoPath, c.String("changelog-file"))
+			err = changelog.WriteRelease(changelogFile, notes.String())
+			if err == nil {
+				err = utils.AddFile(options.RepoPath, c.String("changelog-file"))
+			}
+			if err != nil {
+				if rollbackErr := changelog.RevertReleaseMoves(options.RepoPath, moves); rollbackErr != nil {
+					return fmt.Errorf("%v; rolling back the moves also failed: %v", err, rollbackErr)
+				}
+				return err
+			}
+
+			logger.Info("released changelog entries", "count", len(moves), "file", changelogFile)
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReleaseRefusesInvalidEntries(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+	dir := newFixtureRepo(t)
+	// Leave the invalid entry as the only one that cannot be released.
+	runGit(t, dir, "rm", "-q", "changelog/unreleased/kong/orphan.yml")
+	runGit(t, dir, "commit", "-q", "--no-gpg-sign", "-m", "drop orphan")
+	commitFile(t, dir, "changelog/unreleased/kong/invalid.yml",
+		"message: Has an unknown type.\ntype: nonsense\n",
+		"fix: unknown type (#12)")
+	head := runGit(t, dir, "rev-parse", "HEAD")
+
+	_, err := runApp(t, "release",
+		"--repo-path", dir,
+		"--changelog-paths", "changelog/unreleased/kong",
+		"--github-issue-repo", "Kong/kong",
+		"--github-api-repo", "Kong/kong",
+		"--version", "3.14.0.9",
+		"--replay-http", filepath.Join("testdata", "github"),
+	)
+	if err == nil || !strings.Contains(err.Error(), "refusing to release 3.14.0.9: 1 changelog entries") {
+		t.Fatalf("err = %v, want a refusal", err)
+	}
+
+	if status := runGit(t, dir, "status", "--porcelain", "--untracked-files=all"); status != "" {
+		t.Fatalf("release changed the tree:\n%s", status)
+	}
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Fatalf("HEAD moved from %s to %s", head, got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog", "3.14.0.9")); !os.IsNotExist(err) {
+		t.Fatalf("version folder created: %v", err)
+	}
+}
+
+func TestReleaseMovesOnlyReleasedEntries(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+	dir := newFixtureRepo(t)
+	// fix-crash.yml already shipped in 3.14.0.8, so it is not in the notes.
+	runGit(t, dir, "tag", "3.14.0.8", "HEAD~2")
+	runGit(t, dir, "rm", "-q", "changelog/unreleased/kong/orphan.yml")
+	runGit(t, dir, "commit", "-q", "--no-gpg-sign", "-m", "drop orphan")
+
+	_, err := runApp(t, "release",
+		"--repo-path", dir,
+		"--changelog-paths", "changelog/unreleased/kong",
+		"--github-issue-repo", "Kong/kong",
+		"--github-api-repo", "Kong/kong",
+		"--version", "3.14.0.9",
+		"--since", "3.14.0.8",
+		"--replay-http", filepath.Join("testdata", "github"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := runGit(t, dir, "status", "--porcelain", "--untracked-files=all")
+	want := "A  CHANGELOG.md\nR  changelog/unreleased/kong/acl-groups.yml -> changelog/3.14.0.9/kong/acl-groups.yml\n"
+	if status != want {
+		t.Fatalf("status = %q, want %q", status, want)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "nested groups") || strings.Contains(string(content), "early") {
+		t.Fatalf("CHANGELOG.md:\n%s", content)
+	}
+}
@@ -1,14 +1,10 @@
 package cmd
 
 import (
+	"github.com/Kong/changelog/utils"
 	"github.com/urfave/cli/v2"
 )
 
-// global flags
-var (
-	debug bool
-)
-
 func New() *cli.App {
 	app := &cli.App{
 		Name:        "changelog",
@@ -21,16 +17,53 @@ func New() *cli.App {
 		// global flags
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
-				Name:        "debug",
-				Usage:       "debug mode",
-				Required:    false,
-				Destination: &debug,
+				Name:     "debug",
+				Usage:    "debug mode, same as --log-level debug",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "log-level",
+				Usage:    "The minimum level of the logs written to stderr (debug, info, warn, error)",
+				Value:    "info",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "log-format",
+				Usage:    "The format of the logs (text, json)",
+				Value:    logFormatText,
+				Required: false,
+			},
+			&cli.BoolFlag{
+				Name:     "quiet",
+				Usage:    "Only print the summary at the end of a run, and errors",
+				Required: false,
 			},
 		},
 
+		Before: func(c *cli.Context) error {
+			level := c.String("log-level")
+			if c.Bool("debug") {
+				level = "debug"
+			}
+			return setupLogger(c.App.ErrWriter, level, c.String("log-format"), c.Bool("quiet"))
+		},
+
 		// commands
 		Commands: []*cli.Command{
 			newGenerateCmd(),
+			newNewCmd(),
+			newReleaseCmd(),
+			newForwardPortCheckCmd(),
+			newBackportStatusCmd(),
+			newValidateCmd(),
+			newLintCmd(),
+			newSchemaCmd(),
+		},
+
+		// stop the git processes the utils package still keeps open, e.g.
+		// for the refs checked before building a generator
+		After: func(c *cli.Context) error {
+			return utils.CloseRepositories()
 		},
 	}
 
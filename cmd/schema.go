@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/urfave/cli/v2"
+)
+
+func newSchemaCmd() *cli.Command {
+	cmd := &cli.Command{
+		Name:        "schema",
+		Usage:       "changelog schema [options]",
+		Description: "The schema command prints the JSON Schema of the changelog entry files, derived from the rules of validate and the given config; changelog-schema.json is the one of the default config",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "config",
+				Usage:    "The changelog config file declaring the allowed types, scopes, editions and products",
+				Required: false,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			config, err := changelog.LoadConfig(c.String("config"))
+			if err != nil {
+				return err
+			}
+
+			schema, err := config.JSONSchema()
+			if err != nil {
+				return err
+			}
+			_, err = c.App.Writer.Write(schema)
+			return err
+		},
+	}
+
+	return cmd
+}
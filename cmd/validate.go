@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Kong/changelog/pkg/changelog"
+	"github.com/urfave/cli/v2"
+)
+
+const formatText = "text"
+
+// entryFiles returns the entry files named by paths, descending into the
+// directories among them, sorted and without duplicates.
+func entryFiles(paths []string) ([]string, error) {
+	seen := make(map[string]bool)
+	files := make([]string, 0)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(file) == ".yml" && !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// writeDiagnostics writes diagnostics to w one per line, or as a JSON array
+// for formatJSON.
+func writeDiagnostics(w io.Writer, format string, diagnostics []changelog.Diagnostic) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diagnostics)
+	}
+	for _, d := range diagnostics {
+		if _, err := fmt.Fprintln(w, d.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newValidateCmd() *cli.Command {
+	cmd := &cli.Command{
+		Name:        "validate",
+		Usage:       "changelog validate [options] <file or folder>...",
+		Description: "The validate command checks changelog entry files against the schema and the config, reporting every violation as file:line:column; with --fix, it first fixes the unambiguous ones",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "config",
+				Usage:    "The changelog config file declaring the allowed types, scopes, editions and products",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "format",
+				Usage:    "The output format (text, json)",
+				Value:    formatText,
+				Required: false,
+			},
+			&cli.BoolFlag{
+				Name:     "fix",
+				Usage:    "Rewrite the entry files in place to fix the unambiguous mistakes (case, type and scope aliases, plugin names not in bold, lower case Jira IDs, trailing whitespace), and print the changes as a diff",
+				Required: false,
+			},
+			githubActionsFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("no changelog files given, usage: %s", c.Command.Usage)
+			}
+
+			format := c.String("format")
+			if format != formatText && format != formatJSON {
+				return fmt.Errorf("unknown format %q, must be one of: %s, %s", format, formatText, formatJSON)
+			}
+
+			config, err := changelog.LoadConfig(c.String("config"))
+			if err != nil {
+				return err
+			}
+
+			files, err := entryFiles(c.Args().Slice())
+			if err != nil {
+				return err
+			}
+
+			// The diffs of --fix go with the diagnostics, unless those are
+			// JSON.
+			diffOut := c.App.Writer
+			if format == formatJSON {
+				diffOut = c.App.ErrWriter
+			}
+
+			diagnostics := make([]changelog.Diagnostic, 0)
+			invalid, fixed := 0, 0
+			for _, file := range files {
+				content, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if c.Bool("fix") {
+					fixedContent, err := config.FixFile(content)
+					if err != nil {
+						return fmt.Errorf("failed to fix %s: %w", file, err)
+					}
+					if !bytes.Equal(fixedContent, content) {
+						if err := os.WriteFile(file, fixedContent, 0o644); err != nil {
+							return err
+						}
+						fmt.Fprint(diffOut, unifiedDiff(file, string(content), string(fixedContent)))
+						content = fixedContent
+						fixed++
+					}
+				}
+				fileDiagnostics := config.ValidateFile(file, content)
+				if len(fileDiagnostics) > 0 {
+					invalid++
+				}
+				logger.Debug("validated changelog file", "file", file, "diagnostics", len(fileDiagnostics))
+				diagnostics = append(diagnostics, fileDiagnostics...)
+			}
+
+			if c.Bool("fix") {
+				logger.Info("fixed changelog entries", "count", fixed)
+			}
+
+			if report := newActionsReport(c); report != nil {
+				report.annotateDiagnostics("Invalid changelog entry", diagnostics)
+			}
+
+			if err := writeDiagnostics(c.App.Writer, format, diagnostics); err != nil {
+				return err
+			}
+
+			if invalid > 0 {
+				return fmt.Errorf("invalid changelog entries: %d of %d", invalid, len(files))
+			}
+			logger.Info("all changelog entries are valid", "count", len(files))
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_WORKSPACE", dir)
+	entries := map[string]string{
+		"kong/valid.yml":   "message: Fixed an issue\ntype: bugfix\nscope: Core\n",
+		"kong/invalid.yml": "message: Fixed an issue\ntype: fix\nscope: core\n",
+		"kong/notes.txt":   "not an entry",
+	}
+	for name, content := range entries {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out, errOut bytes.Buffer
+	app := New()
+	app.Writer = &out
+	app.ErrWriter = &errOut
+	err := app.Run([]string{"changelog", "--quiet", "validate", filepath.Join(dir, "kong")})
+	if err == nil || err.Error() != "invalid changelog entries: 1 of 2" {
+		t.Fatalf("validate error = %v, want 1 of 2 entries invalid", err)
+	}
+
+	invalid := filepath.Join(dir, "kong", "invalid.yml")
+	want := invalid + `:2:7: unknown type "fix", must be one of: feature, bugfix, dependency, deprecation, breaking_change, performance (did you mean "bugfix"?)` + "\n" +
+		invalid + `:3:8: unknown scope "core", must be one of: Core, Plugin, PDK, Admin API, Performance, Configuration, Clustering, Portal, CLI Command (did you mean "Core"?)` + "\n"
+	if out.String() != want {
+		t.Fatalf("validate output =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	annotation := `::error file=kong/invalid.yml,line=3,col=8,title=Invalid changelog entry::unknown scope "core"`
+	if !strings.Contains(errOut.String(), annotation) {
+		t.Fatalf("stderr has no %q:\n%s", annotation, errOut.String())
+	}
+}
+
+func TestValidateFix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fix_acl.yml")
+	if err := os.WriteFile(path, []byte("message: Fixed an issue\ntype: fix # was a bug\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runApp(t, "--quiet", "validate", "--fix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- " + path + "\n+++ " + path + "\n@@ -1,2 +1,2 @@\n message: Fixed an issue\n-type: fix # was a bug\n+type: bugfix # was a bug\n"
+	if out != want {
+		t.Fatalf("validate --fix output =\n%s\nwant\n%s", out, want)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(content), "message: Fixed an issue\ntype: bugfix # was a bug\n"; got != want {
+		t.Fatalf("fixed file =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,15 +1,15 @@
 module github.com/Kong/changelog
 
-go 1.20
+go 1.21
 
 require (
+	github.com/google/go-github/v56 v56.0.1-0.20231025210020-5b34ea781649
 	github.com/urfave/cli/v2 v2.25.7
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
-	github.com/google/go-github/v56 v56.0.1-0.20231025210020-5b34ea781649 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
@@ -1,14 +1,16 @@
 package main
 
 import (
-	"github.com/Kong/changelog/cmd"
+	"fmt"
 	"os"
+
+	"github.com/Kong/changelog/cmd"
 )
 
 func main() {
 	app := cmd.New()
 	if err := app.Run(os.Args); err != nil {
-		cmd.Error("Error: %s\n", err.Error())
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
 		os.Exit(1)
 	}
 }
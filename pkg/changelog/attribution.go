@@ -0,0 +1,175 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Kong/changelog/utils"
+)
+
+// mergePullRequestPattern matches the subject GitHub gives the merge commit of
+// a PR merged with "Create a merge commit", e.g.
+// "Merge pull request #1234 from someone/branch".
+var mergePullRequestPattern = regexp.MustCompile(`^Merge pull request #(\d+) from `)
+
+// fetchMergedPullRequestFromMergeCommit resolves the PR of a commit that was
+// merged into ref with a merge commit rather than squashed: the commit lives on
+// the PR branch, so the PR number is read locally from the subject of the
+// mainline merge commit that brought it in. It returns nil when there is no
+// such merge commit or its PR does not match.
+func (g *Generator) fetchMergedPullRequestFromMergeCommit(commit, ref string) (*PullRequestContext, error) {
+	merge := utils.FindMergeCommit(g.options.RepoPath, commit, ref)
+	if merge == "" {
+		return nil, nil
+	}
+
+	message, err := utils.CommitMessage(g.options.RepoPath, merge)
+	if err != nil {
+		return nil, err
+	}
+	match := mergePullRequestPattern.FindStringSubmatch(message)
+	if match == nil {
+		g.logger.Debug("merge commit is not a pull request merge", "sha", commit, "merge", merge)
+		return nil, nil
+	}
+	prNumber, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil, nil
+	}
+
+	pr, err := g.resolver.PullRequest(prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch PR #%d for merge commit %s: %v", prNumber, merge, err)
+	}
+	if pr == nil {
+		return nil, nil
+	}
+	if pr.MergedAt.IsZero() || pr.MergeCommitSHA != merge {
+		g.logger.Debug("PR does not match merge commit", "pr", prNumber, "merge", merge)
+		return nil, nil
+	}
+
+	return pr, nil
+}
+
+// resolveMergedPR finds the merged PR that introduced the given commit on ref:
+// through the resolver, or else from the local merge commit that brought it
+// into ref. A commit whose subject names its PR as "(#1234)" was squash-merged,
+// so a merge commit bringing it in later is that of a sync PR, not its own.
+func (g *Generator) resolveMergedPR(commit, ref string) (*PullRequestContext, error) {
+	mergedPR, err := g.resolver.MergedPullRequest(commit)
+	if err != nil || mergedPR != nil {
+		return mergedPR, err
+	}
+
+	if message, err := utils.CommitMessage(g.options.RepoPath, commit); err == nil {
+		subject, _, _ := strings.Cut(message, "\n")
+		if pullRequestRefPattern.MatchString(subject) {
+			return nil, nil
+		}
+	}
+
+	mergedPR, err = g.fetchMergedPullRequestFromMergeCommit(commit, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve merged PR from merge commit: %v", err)
+	}
+	if mergedPR != nil {
+		g.logger.Debug("resolved merged PR from merge commit", "sha", commit, "pr", mergedPR.Number, "merge", mergedPR.MergeCommitSHA)
+	}
+	return mergedPR, nil
+}
+
+// attributionCandidate is a commit to resolve a changelog entry's PR from.
+type attributionCandidate struct {
+	SHA string
+	// SourceBranch is the source branch hop SHA was found on, or "" for the
+	// introducing commit and the upstream cherry-pick source fallbacks.
+	SourceBranch string
+	// Via is the provenance signal that located SHA on SourceBranch (see
+	// ProvenanceFinder.find).
+	Via string
+}
+
+// releaseLineCandidates returns the commits to resolve a changelog entry's PR
+// from, in priority order, so the entry is attributed to the PR of its commit
+// on the release line (Options.SourceBranches, e.g. next/3.14.x.x) — the
+// release-line PR — rather than the upstream/master PR or the sync PR.
+//
+// Each source branch hop is tried in turn (see ProvenanceFinder.find), nearest
+// first, and every counterpart found is kept so the PR is resolved from the
+// nearest hop that has one, falling back outward. When no hop has a
+// counterpart, the recorded upstream cherry-pick source (if any) is used as
+// the best available origin. The introducing commit is always kept as the
+// last fallback so an entry is never dropped when the preferred commit has no
+// resolvable merged PR.
+func (g *Generator) releaseLineCandidates(commit, filename string) []attributionCandidate {
+	cherryPickSource := g.attribution.cherryPickSourceOf(commit)
+
+	candidates := make([]attributionCandidate, 0, len(g.options.SourceBranches)+1)
+	seen := make(map[string]bool)
+	add := func(candidate attributionCandidate) {
+		if !seen[candidate.SHA] {
+			seen[candidate.SHA] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for _, branch := range g.options.SourceBranches {
+		if presence, ok := g.attribution.find(branch, commit, filename, cherryPickSource); ok {
+			add(attributionCandidate{SHA: presence.Commit, SourceBranch: branch, Via: presence.Via})
+		}
+	}
+
+	// Last resort: the recorded upstream source (if any) as the best available
+	// origin, otherwise the introducing commit itself.
+	if len(candidates) == 0 && len(g.options.SourceBranches) > 0 && cherryPickSource() != "" {
+		add(attributionCandidate{SHA: cherryPickSource(), Via: presenceTrailer})
+	}
+	add(attributionCandidate{SHA: commit})
+
+	return candidates
+}
+
+func (g *Generator) fetchCommitContext(filename string) (ctx CommitContext, err error) {
+	commit, err := g.attribution.history.FindOriginalCommit(g.options.ToRef, filename)
+	if err != nil {
+		return
+	}
+	ctx.SHA = commit
+	ctx.OriginalSHA = commit
+	g.logger.Debug("found original commit", "file", filename, "sha", commit)
+
+	candidates := g.releaseLineCandidates(commit, filename)
+	if candidates[0].SHA != commit {
+		g.logger.Debug("attributed to release-line commit", "file", filename, "sha", commit, "release_sha", candidates[0].SHA, "branch", candidates[0].SourceBranch, "via", candidates[0].Via, "candidates", fmt.Sprintf("%+v", candidates))
+	}
+
+	var mergedPR *PullRequestContext
+	for _, candidate := range candidates {
+		ref := candidate.SourceBranch
+		if ref == "" {
+			ref = g.options.ToRef
+		}
+		if ref == "" {
+			ref = "HEAD"
+		}
+		mergedPR, err = g.resolveMergedPR(candidate.SHA, ref)
+		if err != nil {
+			return ctx, err
+		}
+		if mergedPR != nil {
+			ctx.SHA = candidate.SHA
+			ctx.SourceBranch = candidate.SourceBranch
+			break
+		}
+	}
+
+	if mergedPR == nil {
+		return ctx, &MissingPullRequestError{CommitSHA: ctx.SHA}
+	}
+
+	ctx.PrCtx = *mergedPR
+	return ctx, nil
+}
@@ -0,0 +1,59 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kong/changelog/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// BackportStatus is the presence of one entry's change on each branch; a
+// branch maps to nil when the change is missing from it.
+type BackportStatus struct {
+	File     string               `json:"file"`
+	Message  string               `json:"message"`
+	Commit   string               `json:"commit"`
+	Branches map[string]*Presence `json:"branches"`
+}
+
+// CollectBackportStatus reports, for each entry under changelogPaths at ref,
+// on which of branches its change is present.
+func (f *ProvenanceFinder) CollectBackportStatus(ref string, changelogPaths, branches []string) ([]BackportStatus, error) {
+	entries, err := f.listBranchEntries(ref, changelogPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(entries)
+	statuses := make([]BackportStatus, 0, total)
+	for i, entry := range entries {
+		f.logger.Info("checking changelog file", "file", entry.File, "sha", entry.Commit, "index", i+1, "total", total)
+
+		status := BackportStatus{
+			File:     entry.File,
+			Commit:   entry.Commit,
+			Branches: make(map[string]*Presence, len(branches)),
+		}
+
+		content, err := utils.ReadFileAt(f.repoPath, ref, entry.File)
+		if err != nil {
+			return nil, err
+		}
+		parsed := &ChangelogEntry{}
+		if err := yaml.Unmarshal(content, parsed); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal YAML from %s: %v", entry.File, err)
+		}
+		status.Message = strings.TrimSpace(parsed.Message)
+
+		for _, branch := range branches {
+			if presence, ok := f.findOnBranch(branch, entry.Commit, entry.File); ok {
+				status.Branches[branch] = &presence
+			} else {
+				status.Branches[branch] = nil
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,185 @@
+// Package changelog collects the changelog entry files of a repository,
+// attributes each to the pull request that introduced it and renders them as
+// release notes.
+//
+// A Generator is built from explicit Options, a Config, a PullRequestResolver
+// and a *slog.Logger, so that tools other than the changelog command can reuse
+// it:
+//
+//	resolver := changelog.NewGitHubResolver(client, "Kong", "kong", logger)
+//	generator := changelog.NewGenerator(options, changelog.DefaultConfig(), resolver, logger)
+//	data, failures, err := generator.Collect()
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const (
+	// PluginHeadingsEach lists an entry touching several plugins under each of
+	// them, PluginHeadingsCombined under one heading naming them all.
+	PluginHeadingsEach     = "each"
+	PluginHeadingsCombined = "combined"
+
+	// ShippedOmit leaves out the entries already shipped in Options.Since,
+	// ShippedFlag keeps them and only reports them.
+	ShippedOmit = "omit"
+	ShippedFlag = "flag"
+
+	// RevertedOmit leaves out the entries whose change was reverted,
+	// RevertedWarn keeps them and reports them, RevertedNote also renders them
+	// with a Reverted note.
+	RevertedOmit = "omit"
+	RevertedWarn = "warn"
+	RevertedNote = "note"
+
+	// DuplicatesWarn keeps the entries repeating another one and reports
+	// them, DuplicatesMerge merges those repeating its message, type, scope
+	// and plugins into it.
+	DuplicatesWarn  = "warn"
+	DuplicatesMerge = "merge"
+)
+
+// Options selects the entries a Generator collects and how they are attributed
+// and rendered.
+type Options struct {
+	Title string
+
+	// TemplatePath is a custom template file to render instead of the
+	// embedded changelog-markdown.tmpl. It receives the same TemplateData.
+	TemplatePath string
+
+	RepoPath string
+
+	ChangelogPaths []string
+
+	// SourceBranches are the branches changes flow through into the
+	// fix-release branch being generated, nearest first: typically the minor
+	// branch it was cut from (e.g. origin/next/3.14.x.x), then any intermediate
+	// sync branch, then master. When set, every entry is attributed to the PR
+	// of its commit on the nearest of these branches where it is found — the
+	// release-line (backport) PR — rather than the upstream/master PR or the
+	// sync PR. Empty uses the introducing commit directly (backward-compatible).
+	SourceBranches []string
+
+	// PatchID enables matching entries to their source branch counterparts
+	// by patch ID (see ProvenanceFinder.findByPatchID).
+	PatchID string
+
+	WithJiras bool
+
+	// Infer fills in the type and scope of entries that omit them from the
+	// attributed PR's title and changed paths (see InferenceConfig).
+	Infer bool
+
+	// WithContributors adds a section thanking the credited PR authors (see
+	// ContributorsConfig).
+	WithContributors bool
+
+	// PluginHeadings renders the Plugin scope with one sub-heading per plugin
+	// when set: PluginHeadingsEach or PluginHeadingsCombined. Empty renders the
+	// scope as a flat list.
+	PluginHeadings string
+
+	// FromRef and ToRef, when FromRef is set, select the entries added or
+	// modified between the two revisions instead of those present in the
+	// changelog folders, reading them and their history as of ToRef (HEAD
+	// when empty).
+	FromRef string
+	ToRef   string
+
+	// Since is the tag of the previous release. Entries already shipped in it
+	// (see shippedIndex) are omitted, or kept and reported when Shipped is
+	// ShippedFlag.
+	Since   string
+	Shipped string
+
+	// Reverted is what to do with the entries whose change was reverted on
+	// ToRef (see Generator.revertedBy): RevertedOmit, RevertedWarn (the
+	// default) or RevertedNote.
+	Reverted string
+
+	// Duplicates is what to do with the entries repeating another one (see
+	// Generator.findDuplicates): DuplicatesWarn or DuplicatesMerge.
+	Duplicates string
+
+	// Strict validates every entry against the full schema (see
+	// Config.ValidateFile) rather than only its config-driven fields.
+	Strict bool
+
+	// Edition limits the output to entries that apply to this edition (one of
+	// the configured editions). Empty includes every entry.
+	Edition string
+
+	GithubIssueRepo string
+
+	// ScopePriority orders the scopes within a section, lowest first; nil uses
+	// DefaultScopePriority.
+	ScopePriority map[string]int
+}
+
+// DefaultScopePriority returns the scope order of the Kong changelogs.
+func DefaultScopePriority() map[string]int {
+	return map[string]int{
+		"Performance":   10,
+		"Configuration": 20,
+		"Core":          30,
+		"PDK":           40,
+		"Plugin":        50,
+		"Admin API":     60,
+		"Clustering":    70,
+		"Default":       100, // default priority
+	}
+}
+
+// Validate checks the modes and the edition of the options against config.
+func (o *Options) Validate(config Config) error {
+	if o.Edition != "" && len(config.Editions) > 0 && !contains(config.Editions, o.Edition) {
+		return fmt.Errorf("unknown edition %q, must be one of: %s", o.Edition, strings.Join(config.Editions, ", "))
+	}
+
+	if o.PluginHeadings != "" && o.PluginHeadings != PluginHeadingsEach && o.PluginHeadings != PluginHeadingsCombined {
+		return fmt.Errorf("unknown plugin-headings %q, must be one of: %s, %s", o.PluginHeadings, PluginHeadingsEach, PluginHeadingsCombined)
+	}
+
+	if err := ValidatePatchIDMode(o.PatchID); err != nil {
+		return err
+	}
+
+	if o.Shipped != "" && o.Shipped != ShippedOmit && o.Shipped != ShippedFlag {
+		return fmt.Errorf("unknown shipped %q, must be one of: %s, %s", o.Shipped, ShippedOmit, ShippedFlag)
+	}
+
+	if o.Reverted != "" && o.Reverted != RevertedOmit && o.Reverted != RevertedWarn && o.Reverted != RevertedNote {
+		return fmt.Errorf("unknown reverted %q, must be one of: %s, %s, %s", o.Reverted, RevertedOmit, RevertedWarn, RevertedNote)
+	}
+
+	if o.Duplicates != "" && o.Duplicates != DuplicatesWarn && o.Duplicates != DuplicatesMerge {
+		return fmt.Errorf("unknown duplicates %q, must be one of: %s, %s", o.Duplicates, DuplicatesWarn, DuplicatesMerge)
+	}
+
+	return nil
+}
+
+// discardLogger returns the logger of a nil *slog.Logger argument.
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// PullRequestResolver looks up the pull requests entries are attributed to.
+type PullRequestResolver interface {
+	// MergedPullRequest returns the merged PR that introduced commit, or nil
+	// when there is none.
+	MergedPullRequest(commit string) (*PullRequestContext, error)
+
+	// PullRequest returns the PR with the given number, or nil when it does
+	// not exist.
+	PullRequest(number int) (*PullRequestContext, error)
+
+	// UserName returns the display name of the user with the given login, or
+	// "" when it is unset or cannot be fetched.
+	UserName(login string) string
+}
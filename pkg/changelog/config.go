@@ -0,0 +1,100 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the repository-level changelog configuration. It declares the
+// values entries may use for fields whose allowed set differs between repos.
+type Config struct {
+	// Types lists the allowed entry types.
+	Types []string `yaml:"types"`
+
+	// Scopes lists the allowed entry scopes.
+	Scopes []string `yaml:"scopes"`
+
+	// Editions lists the editions an entry may be restricted to, or none to
+	// accept any. An entry without editions applies to all of them.
+	Editions []string `yaml:"editions"`
+
+	// Products lists the products an entry may be restricted to, or none to
+	// accept any. An entry without products applies to all of them.
+	Products []string `yaml:"products"`
+
+	// Contributors selects the PR authors thanked in the contributors section.
+	Contributors ContributorsConfig `yaml:"contributors"`
+
+	// Inference maps PR titles and changed paths to types and scopes for
+	// entries that omit them.
+	Inference InferenceConfig `yaml:"inference"`
+
+	// Lint turns the message style rules of the lint command on or off.
+	Lint LintConfig `yaml:"lint"`
+}
+
+// DefaultConfig returns the configuration of the Kong repositories, used for
+// every key a configuration file omits.
+func DefaultConfig() Config {
+	return Config{
+		Types: []string{"feature", "bugfix", "dependency", "deprecation", "breaking_change", "performance"},
+		Scopes: []string{
+			"Core", "Plugin", "PDK", "Admin API", "Performance", "Configuration",
+			"Clustering", "Portal", "CLI Command",
+		},
+		Contributors: ContributorsConfig{
+			Associations: []string{"CONTRIBUTOR", "FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER", "NONE"},
+		},
+		Inference: defaultInferenceConfig(),
+		Lint:      defaultLintConfig(),
+	}
+}
+
+// LoadConfig reads the configuration file at path, falling back to the
+// defaults for every key the file omits. An empty path yields the defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config %s: %v", path, err)
+	}
+
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to unmarshal config %s: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// restricts reports whether values narrows the entry to a strict subset of
+// allowed, i.e. whether it is worth a badge. Any values do when allowed is
+// not declared.
+func restricts(values, allowed []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if !contains(values, a) {
+			return true
+		}
+	}
+	return false
+}
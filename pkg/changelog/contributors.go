@@ -0,0 +1,77 @@
+package changelog
+
+import (
+	"sort"
+	"strings"
+)
+
+// Contributor is the author of the PR an entry is attributed to.
+type Contributor struct {
+	Login string
+	Name  string
+	// Association is the author's GitHub association with the repository
+	// (e.g. MEMBER, CONTRIBUTOR, FIRST_TIME_CONTRIBUTOR).
+	Association string
+	Bot         bool
+}
+
+// ContributorsConfig selects which PR authors are credited in the
+// contributors section.
+type ContributorsConfig struct {
+	// Associations lists the author associations that are credited, so
+	// organization members are left out by default.
+	Associations []string `yaml:"associations"`
+
+	// Allow lists logins that are always credited, regardless of association.
+	Allow []string `yaml:"allow"`
+
+	// Deny lists logins that are never credited (e.g. employees outside the
+	// organization, or bots not flagged as such by GitHub).
+	Deny []string `yaml:"deny"`
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// credited reports whether the contributor belongs in the contributors
+// section according to cfg.
+func (cfg *ContributorsConfig) credited(contributor *Contributor) bool {
+	if contributor.Login == "" || containsFold(cfg.Deny, contributor.Login) {
+		return false
+	}
+	if containsFold(cfg.Allow, contributor.Login) {
+		return true
+	}
+	return !contributor.Bot && contains(cfg.Associations, contributor.Association)
+}
+
+// collectContributors returns the credited authors of the collected entries,
+// once each, sorted by login.
+func (g *Generator) collectContributors(maps map[string]map[string][]*ChangelogEntry) []*Contributor {
+	seen := make(map[string]*Contributor)
+	for _, scopeEntries := range maps {
+		for _, entries := range scopeEntries {
+			for _, entry := range entries {
+				if entry.Author == nil || !g.config.Contributors.credited(entry.Author) {
+					continue
+				}
+				seen[strings.ToLower(entry.Author.Login)] = entry.Author
+			}
+		}
+	}
+
+	list := make([]*Contributor, 0, len(seen))
+	for _, contributor := range seen {
+		list = append(list, contributor)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Login) < strings.ToLower(list[j].Login)
+	})
+	return list
+}
@@ -0,0 +1,110 @@
+package changelog
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v56/github"
+)
+
+func TestContributorsConfigCredited(t *testing.T) {
+	cfg := ContributorsConfig{
+		Associations: []string{"CONTRIBUTOR", "FIRST_TIME_CONTRIBUTOR"},
+		Allow:        []string{"Friend"},
+		Deny:         []string{"employee"},
+	}
+
+	tests := []struct {
+		name        string
+		contributor Contributor
+		want        bool
+	}{
+		{name: "credited association", contributor: Contributor{Login: "alice", Association: "CONTRIBUTOR"}, want: true},
+		{name: "member", contributor: Contributor{Login: "bob", Association: "MEMBER"}, want: false},
+		{name: "allowed member", contributor: Contributor{Login: "friend", Association: "MEMBER"}, want: true},
+		{name: "denied contributor", contributor: Contributor{Login: "Employee", Association: "CONTRIBUTOR"}, want: false},
+		{name: "bot", contributor: Contributor{Login: "renovate[bot]", Association: "CONTRIBUTOR", Bot: true}, want: false},
+		{name: "allowed bot", contributor: Contributor{Login: "friend", Association: "NONE", Bot: true}, want: true},
+		{name: "no login", contributor: Contributor{Association: "CONTRIBUTOR"}, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cfg.credited(&tc.contributor); got != tc.want {
+				t.Fatalf("credited(%+v) = %v, want %v", tc.contributor, got, tc.want)
+			}
+		})
+	}
+}
+
+// namedResolver is a fakeResolver knowing the names of some users.
+type namedResolver struct {
+	fakeResolver
+	names map[string]string
+}
+
+func (r namedResolver) UserName(login string) string {
+	return r.names[login]
+}
+
+func TestGeneratorAuthorName(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	fixed := commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: Fixed a crash.\ntype: bugfix\n", "fix: crash (#10)")
+
+	resolver := namedResolver{
+		fakeResolver: fakeResolver{
+			fixed: {Number: 10, Title: "fix: crash", MergedAt: time.Now(), MergeCommitSHA: fixed, Author: Contributor{Login: "alice", Association: "MEMBER"}},
+		},
+		names: map[string]string{"alice": "Alice Liddell"},
+	}
+	// The name is resolved without WithContributors, for the templates.
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+	}, DefaultConfig(), resolver, nil)
+
+	data, _, err := generator.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := data.Type["bugfix"][0].Entries[0]
+	if entry.Author.Name != "Alice Liddell" || entry.PullRequest.Author.Name != "Alice Liddell" {
+		t.Fatalf("author = %+v, want the name of alice", entry.Author)
+	}
+}
+
+func TestGitHubResolverUserNameCachesFailures(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.URL.Path != "/users/alice" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"login": "alice", "name": "Alice Liddell"}`)
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	resolver := NewGitHubResolver(client, "Kong", "kong", nil)
+
+	for i := 0; i < 2; i++ {
+		if got := resolver.UserName("alice"); got != "Alice Liddell" {
+			t.Fatalf("UserName(alice) = %q", got)
+		}
+		if got := resolver.UserName("ghost"); got != "" {
+			t.Fatalf("UserName(ghost) = %q", got)
+		}
+	}
+	if requests["/users/alice"] != 1 || requests["/users/ghost"] != 1 {
+		t.Fatalf("requests = %v, want one per user", requests)
+	}
+}
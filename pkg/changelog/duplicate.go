@@ -0,0 +1,229 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// duplicateSimilarity is the share of their words two messages must have in
+// common to be near-duplicates.
+const duplicateSimilarity = 0.8
+
+// messageWords returns the lower-cased words of message, without the Markdown
+// and the punctuation around them (version numbers such as 3.1 are kept
+// whole).
+func messageWords(message string) []string {
+	words := make([]string, 0)
+	for _, field := range strings.Fields(strings.ToLower(message)) {
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// wordSimilarity returns the Jaccard index of the word sets of a and b.
+func wordSimilarity(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, word := range a {
+		set[word] = true
+	}
+	union := len(set)
+	common := 0
+	seen := make(map[string]bool, len(b))
+	for _, word := range b {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		if set[word] {
+			common++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(common) / float64(union)
+}
+
+// relativePath returns file relative to the repository, for the reports.
+func (g *Generator) relativePath(file string) string {
+	if rel, err := filepath.Rel(g.options.RepoPath, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return file
+}
+
+// entryWords returns the words of the entry's message, with the plugin names
+// it may have been stripped of.
+func entryWords(entry *ChangelogEntry) []string {
+	return messageWords(strings.Join(entry.Plugins, " ") + " " + entry.Message)
+}
+
+// duplicateOf returns the entry of kept that entry duplicates, with the
+// reason, and whether entry can be merged into it: only an entry with the
+// same message (word for word, in order), type, scope and plugins can. A
+// similar message or a shared PR and type is only reported, as merging it
+// could drop a different change, such as the same fix in another plugin. It
+// returns nil when entry duplicates none of them.
+func (g *Generator) duplicateOf(entry *ChangelogEntry, kept []*ChangelogEntry) (*ChangelogEntry, string, bool) {
+	words := entryWords(entry)
+	for _, original := range kept {
+		originalWords := entryWords(original)
+		if strings.Join(words, " ") == strings.Join(originalWords, " ") {
+			reason := "same message as " + g.relativePath(original.fileName)
+			if !sameKind(entry, original) {
+				return original, reason + ", with another type, scope or plugins", false
+			}
+			return original, reason, true
+		}
+		if similarity := wordSimilarity(words, originalWords); similarity >= duplicateSimilarity {
+			return original, fmt.Sprintf("message %.0f%% similar to %s", similarity*100, g.relativePath(original.fileName)), false
+		}
+	}
+	for _, original := range kept {
+		if entry.PullRequest != nil && original.PullRequest != nil && entry.PullRequest.Number != 0 &&
+			entry.PullRequest.Number == original.PullRequest.Number && entry.Type == original.Type {
+			return original, fmt.Sprintf("same PR #%d and type as %s", entry.PullRequest.Number, g.relativePath(original.fileName)), false
+		}
+	}
+	return nil, "", false
+}
+
+// sameKind reports whether a and b have the same type, scope and plugins.
+func sameKind(a, b *ChangelogEntry) bool {
+	if a.Type != b.Type || a.Scope != b.Scope || len(a.Plugins) != len(b.Plugins) {
+		return false
+	}
+	for i, plugin := range a.Plugins {
+		if b.Plugins[i] != plugin {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeEntry adds the GitHub references and Jira tickets of duplicate to
+// entry.
+func (g *Generator) mergeEntry(entry, duplicate *ChangelogEntry) {
+	for _, no := range duplicate.Githubs {
+		if !containsInt(entry.Githubs, no) {
+			entry.Githubs = append(entry.Githubs, no)
+		}
+	}
+	entry.ParsedGithubs = g.parseGithub(entry.Githubs)
+
+	for _, jira := range duplicate.Jiras {
+		if !contains(entry.Jiras, jira) {
+			entry.Jiras = append(entry.Jiras, jira)
+		}
+	}
+	if g.options.WithJiras {
+		entry.ParsedJiras = make([]*Jira, 0, len(entry.Jiras))
+		for _, jiraId := range entry.Jiras {
+			entry.ParsedJiras = append(entry.ParsedJiras, &Jira{
+				ID:   jiraId,
+				Link: jiraBaseURL + jiraId,
+			})
+		}
+	}
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// findDuplicates reports the entries of maps repeating another one, in file
+// name order: with the same or a similar message, or attributed to the same
+// PR with the same type. With DuplicatesMerge, an entry repeating the message,
+// type, scope and plugins of another one is merged into it and removed from
+// maps; the other duplicates are only reported.
+func (g *Generator) findDuplicates(maps map[string]map[string][]*ChangelogEntry) {
+	entries := make([]*ChangelogEntry, 0)
+	for _, scopeEntries := range maps {
+		for _, list := range scopeEntries {
+			entries = append(entries, list...)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].fileName < entries[j].fileName
+	})
+
+	merged := make(map[*ChangelogEntry]bool)
+	kept := make([]*ChangelogEntry, 0, len(entries))
+	for _, entry := range entries {
+		original, reason, mergeable := g.duplicateOf(entry, kept)
+		if original == nil {
+			kept = append(kept, entry)
+			continue
+		}
+
+		if g.options.Duplicates == DuplicatesMerge && mergeable {
+			g.mergeEntry(original, entry)
+			merged[entry] = true
+			reason += ", merged into it"
+		} else {
+			kept = append(kept, entry)
+		}
+		g.duplicateEntries = append(g.duplicateEntries, SkippedEntry{FileName: entry.fileName, Reason: reason})
+		g.logger.Warn("duplicate changelog entry", "file", entry.fileName, "reason", reason)
+	}
+
+	if len(merged) == 0 {
+		return
+	}
+	for t, scopeEntries := range maps {
+		for scope, list := range scopeEntries {
+			remaining := make([]*ChangelogEntry, 0, len(list))
+			for _, entry := range list {
+				if !merged[entry] {
+					remaining = append(remaining, entry)
+				}
+			}
+			if len(remaining) == 0 {
+				delete(scopeEntries, scope)
+			} else {
+				scopeEntries[scope] = remaining
+			}
+		}
+		if len(scopeEntries) == 0 {
+			delete(maps, t)
+		}
+	}
+}
+
+// Duplicates returns the entries the last Collect found to repeat another
+// one, in file name order.
+func (g *Generator) Duplicates() []SkippedEntry {
+	return g.duplicateEntries
+}
+
+func (g *Generator) writeDuplicateSummary(w io.Writer) {
+	if len(g.duplicateEntries) == 0 {
+		return
+	}
+
+	entryNoun := "entries"
+	if len(g.duplicateEntries) == 1 {
+		entryNoun = "entry"
+	}
+
+	fmt.Fprintf(w, "\nfound %d duplicate changelog %s:\n", len(g.duplicateEntries), entryNoun)
+	for i, duplicate := range g.duplicateEntries {
+		fmt.Fprintf(w, "%d. file: %s\n   reason: %s\n", i+1, duplicate.FileName, duplicate.Reason)
+	}
+}
@@ -0,0 +1,170 @@
+package changelog
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	first := commitFile(t, dir, "changelog/unreleased/kong/a.yml", "message: Fixed a crash in the balancer.\ntype: bugfix\nscope: Core\njiras: [FTI-1]\n", "fix: balancer crash (#10)")
+	backport := commitFile(t, dir, "changelog/unreleased/kong/b.yml", "message: \"Fixed a crash in the **balancer**\"\ntype: bugfix\nscope: Core\njiras: [FTI-2]\n", "fix: balancer crash (#11)")
+	samePR := commitFile(t, dir, "changelog/unreleased/kong/c.yml", "message: Fixed the log level.\ntype: bugfix\nscope: Core\n", "fix: log level (#10)")
+	otherType := commitFile(t, dir, "changelog/unreleased/kong/d.yml", "message: Added an option.\ntype: feature\nscope: Core\n", "feat: option (#10)")
+
+	resolver := fakeResolver{
+		first:     {Number: 10, MergedAt: time.Now(), MergeCommitSHA: first},
+		backport:  {Number: 11, MergedAt: time.Now(), MergeCommitSHA: backport},
+		samePR:    {Number: 10, MergedAt: time.Now(), MergeCommitSHA: samePR},
+		otherType: {Number: 10, MergedAt: time.Now(), MergeCommitSHA: otherType},
+	}
+
+	tests := []struct {
+		duplicates string
+		want       []string
+		wantLinks  []string
+	}{
+		{
+			duplicates: DuplicatesWarn,
+			want:       []string{"a.yml", "b.yml", "c.yml"},
+			wantLinks:  []string{"#10"},
+		},
+		{
+			duplicates: DuplicatesMerge,
+			want:       []string{"a.yml", "c.yml"},
+			wantLinks:  []string{"#10", "#11"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.duplicates, func(t *testing.T) {
+			generator := NewGenerator(Options{
+				Title:           "Kong",
+				RepoPath:        dir,
+				ChangelogPaths:  []string{"changelog/unreleased/kong"},
+				GithubIssueRepo: "Kong/kong",
+				WithJiras:       true,
+				Duplicates:      tc.duplicates,
+			}, DefaultConfig(), resolver, nil)
+
+			data, failures, err := generator.Collect()
+			if err != nil || len(failures) != 0 {
+				t.Fatalf("Collect() failures = %+v, err = %v", failures, err)
+			}
+
+			wantDuplicates := []string{
+				"b.yml: same message as changelog/unreleased/kong/a.yml",
+				"c.yml: same PR #10 and type as changelog/unreleased/kong/a.yml",
+			}
+			if tc.duplicates == DuplicatesMerge {
+				wantDuplicates[0] += ", merged into it"
+			}
+			duplicates := generator.Duplicates()
+			if len(duplicates) != len(wantDuplicates) {
+				t.Fatalf("Duplicates() = %+v, want %v", duplicates, wantDuplicates)
+			}
+			for i, duplicate := range duplicates {
+				if got := filepath.Base(duplicate.FileName) + ": " + duplicate.Reason; got != wantDuplicates[i] {
+					t.Fatalf("duplicate %d = %q, want %q", i, got, wantDuplicates[i])
+				}
+			}
+
+			entries := data.Type["bugfix"][0].Entries
+			files := make([]string, 0)
+			for _, entry := range entries {
+				files = append(files, filepath.Base(entry.fileName))
+			}
+			if fmt.Sprint(files) != fmt.Sprint(tc.want) {
+				t.Fatalf("bugfix entries = %v, want %v", files, tc.want)
+			}
+
+			links := make([]string, 0)
+			for _, github := range entries[0].ParsedGithubs {
+				links = append(links, github.Name)
+			}
+			if fmt.Sprint(links) != fmt.Sprint(tc.wantLinks) {
+				t.Fatalf("a.yml links = %v, want %v", links, tc.wantLinks)
+			}
+			if tc.duplicates == DuplicatesMerge && len(entries[0].ParsedJiras) != 2 {
+				t.Fatalf("a.yml jiras = %v, want FTI-1 and FTI-2", entries[0].Jiras)
+			}
+		})
+	}
+}
+
+func TestWordSimilarity(t *testing.T) {
+	a := messageWords("Fixed a crash when the upstream closed the connection early.")
+	b := messageWords("Fixed a crash when the upstream closed the connection.")
+	if got := wordSimilarity(a, b); got < duplicateSimilarity {
+		t.Fatalf("wordSimilarity() = %.2f, want near-duplicates", got)
+	}
+	c := messageWords("Bumped OpenSSL from 3.1 to 3.2.")
+	d := messageWords("Bumped OpenSSL from 3.0 to 3.1.")
+	if got := wordSimilarity(c, d); got >= duplicateSimilarity {
+		t.Fatalf("wordSimilarity() = %.2f, want distinct messages", got)
+	}
+}
+
+func TestFindDuplicatesMergesOnlyRepeats(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	files := []struct {
+		name, content string
+	}{
+		{"a.yml", "message: \"**rate-limiting**: Fixed an issue where the plugin failed to start when the Redis cluster mode was enabled.\"\ntype: bugfix\nscope: Plugin\n"},
+		{"b.yml", "message: \"**response-ratelimiting**: Fixed an issue where the plugin failed to start when the Redis cluster mode was enabled.\"\ntype: bugfix\nscope: Plugin\n"},
+		{"c.yml", "message: Fixed an issue where the balancer ignored the upstream timeouts.\ntype: bugfix\nscope: Core\n"},
+		{"d.yml", "message: Fixed an issue where the balancer ignored the upstream timeouts.\ntype: feature\nscope: Core\n"},
+		{"e.yml", "message: Fixed an issue where the balancer ignored the upstream timeouts.\ntype: bugfix\nscope: PDK\n"},
+		{"f.yml", "message: The balancer ignored the upstream timeouts, fixed an issue where.\ntype: bugfix\nscope: Core\n"},
+	}
+	resolver := fakeResolver{}
+	for i, file := range files {
+		commit := commitFile(t, dir, "changelog/unreleased/kong/"+file.name, file.content, fmt.Sprintf("change %d (#%d)", i, 20+i))
+		resolver[commit] = &PullRequestContext{Number: 20 + i, MergedAt: time.Now(), MergeCommitSHA: commit}
+	}
+
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+		Duplicates:      DuplicatesMerge,
+	}, DefaultConfig(), resolver, nil)
+
+	data, failures, err := generator.Collect()
+	if err != nil || len(failures) != 0 {
+		t.Fatalf("Collect() failures = %+v, err = %v", failures, err)
+	}
+
+	wantDuplicates := []string{
+		"b.yml: message 88% similar to changelog/unreleased/kong/a.yml",
+		"d.yml: same message as changelog/unreleased/kong/c.yml, with another type, scope or plugins",
+		"e.yml: same message as changelog/unreleased/kong/c.yml, with another type, scope or plugins",
+		"f.yml: message 100% similar to changelog/unreleased/kong/c.yml",
+	}
+	duplicates := generator.Duplicates()
+	if len(duplicates) != len(wantDuplicates) {
+		t.Fatalf("Duplicates() = %+v, want %v", duplicates, wantDuplicates)
+	}
+	for i, duplicate := range duplicates {
+		if got := filepath.Base(duplicate.FileName) + ": " + duplicate.Reason; got != wantDuplicates[i] {
+			t.Fatalf("duplicate %d = %q, want %q", i, got, wantDuplicates[i])
+		}
+	}
+
+	// None of them repeats the message, type, scope and plugins of another
+	// one, so all are kept.
+	kept := 0
+	for _, scopes := range data.Type {
+		for _, scope := range scopes {
+			kept += len(scope.Entries)
+		}
+	}
+	if kept != len(files) {
+		t.Fatalf("kept %d entries, want %d", kept, len(files))
+	}
+}
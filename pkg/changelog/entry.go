@@ -0,0 +1,335 @@
+package changelog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Kong/changelog/utils"
+)
+
+const (
+	jiraBaseURL = "https://konghq.atlassian.net/browse/"
+
+	pluginScope = "Plugin"
+)
+
+type CommitContext struct {
+	SHA     string
+	Message string
+	PrCtx   PullRequestContext
+	// OriginalSHA is the commit that added the file on the ref, which SHA
+	// differs from when the entry was attributed through a source branch.
+	OriginalSHA string
+	// SourceBranch is the source branch hop SHA was found on, "" when the
+	// entry was not attributed through a source branch.
+	SourceBranch string
+}
+
+type PullRequestContext struct {
+	Number         int
+	Title          string
+	Body           string
+	Author         Contributor
+	URL            string
+	MergedAt       time.Time
+	Labels         []string
+	BaseBranch     string
+	MergeCommitSHA string
+}
+
+// HasLabel reports whether the PR carries the named label, so templates can
+// filter entries with e.g. {{ if .PullRequest.HasLabel "security" }}.
+func (pr *PullRequestContext) HasLabel(name string) bool {
+	return contains(pr.Labels, name)
+}
+
+type MissingPullRequestError struct {
+	CommitSHA string
+}
+
+func (e *MissingPullRequestError) Error() string {
+	return fmt.Sprintf("no merged PR found for commit %s", e.CommitSHA)
+}
+
+type EntryProcessingFailure struct {
+	FileName  string
+	CommitSHA string
+	Err       error
+}
+
+func (e *EntryProcessingFailure) Error() string {
+	var missingPR *MissingPullRequestError
+	if errors.As(e.Err, &missingPR) {
+		return fmt.Sprintf("reason: missing merged PR\nfile: %s\ncommit: %s", e.FileName, missingPR.CommitSHA)
+	}
+
+	if e.CommitSHA == "" {
+		return fmt.Sprintf("file: %s\nerror: %v", e.FileName, e.Err)
+	}
+
+	return fmt.Sprintf("file: %s\ncommit: %s\nerror: %v", e.FileName, e.CommitSHA, e.Err)
+}
+
+func (e *EntryProcessingFailure) Unwrap() error {
+	return e.Err
+}
+
+func entryProcessingFailureFromError(err error, fileName string) *EntryProcessingFailure {
+	var failure *EntryProcessingFailure
+	if errors.As(err, &failure) {
+		if failure.FileName == "" {
+			failure.FileName = fileName
+		}
+		if failure.CommitSHA == "" {
+			var missingPR *MissingPullRequestError
+			if errors.As(failure.Err, &missingPR) {
+				failure.CommitSHA = missingPR.CommitSHA
+			}
+		}
+		return failure
+	}
+
+	var missingPR *MissingPullRequestError
+	if !errors.As(err, &missingPR) {
+		return nil
+	}
+
+	return &EntryProcessingFailure{
+		FileName:  fileName,
+		CommitSHA: missingPR.CommitSHA,
+		Err:       err,
+	}
+}
+
+// pluginPrefixPattern matches the bold plugin names a Plugin-scope message
+// starts with (see Config.JSONSchema), e.g. "**rate-limiting** " or
+// "**kafka-upstream**, **confluent**: ", after an optional "**<X> Only**. "
+// badge. The first group captures the badge, the second the plugin names.
+var pluginPrefixPattern = regexp.MustCompile(`^((?:\*\*[^*\s][^*]* Only\*\*\. )?)((?:\*\*[^*\s](?:[^*]*[^*\s])?\*\*)(?:, ?\*\*[^*\s](?:[^*]*[^*\s])?\*\*)*):? `)
+
+var pluginNamePattern = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+
+func isYAML(filename string) bool {
+	return strings.HasSuffix(filename, ".yml")
+}
+
+type ScopeEntries struct {
+	ScopeName string
+	Entries   []*ChangelogEntry
+	// Groups holds the scope's entries split under sub-headings (plugin names
+	// for the Plugin scope when Options.PluginHeadings is set); nil otherwise.
+	Groups []GroupEntries
+}
+
+type GroupEntries struct {
+	Name    string
+	Entries []*ChangelogEntry
+}
+
+type TemplateData struct {
+	Title        string
+	Type         map[string][]ScopeEntries
+	Contributors []*Contributor
+}
+
+type Jira struct {
+	ID   string
+	Link string
+}
+
+type Github struct {
+	Name string
+	Link string
+}
+
+// ChangelogEntry is a changelog file as read from YAML, completed with the
+// data resolved while processing it. CommitSHA is the commit the entry was
+// attributed to and PullRequest the merged PR resolved from it.
+type ChangelogEntry struct {
+	Message       string   `yaml:"message"`
+	Type          string   `yaml:"type"`
+	Scope         string   `yaml:"scope"`
+	Prs           []int    `yaml:"prs"`
+	Githubs       []int    `yaml:"githubs"`
+	Jiras         []string `yaml:"jiras"`
+	Editions      []string `yaml:"editions"`
+	Products      []string `yaml:"products"`
+	Plugins       []string
+	Author        *Contributor
+	CommitSHA     string
+	SourceBranch  string
+	PullRequest   *PullRequestContext
+	ParsedJiras   []*Jira
+	ParsedGithubs []*Github
+	Badges        []string
+	Inferred      []string
+	ShippedIn     string
+	Reverted      string
+	fileName      string
+	// originalCommitSHA is the commit that added the file on the ref.
+	originalCommitSHA string
+}
+
+func (g *Generator) parseGithub(githubNos []int) []*Github {
+	list := make([]*Github, 0)
+	for _, no := range githubNos {
+		github := &Github{
+			Name: fmt.Sprintf("#%d", no),
+			// Use the /pull/ form so the common case (a resolved PR or a `prs`
+			// entry) links straight to the PR. GitHub redirects /pull/<n> to
+			// /issues/<n> when <n> is actually an issue, so genuine issue
+			// references in the `githubs` field still resolve correctly.
+			Link: fmt.Sprintf("https://github.com/%s/pull/%d", g.options.GithubIssueRepo, no),
+		}
+		list = append(list, github)
+	}
+	return list
+}
+
+// parsePlugins splits a Plugin-scope message into the plugin names it starts
+// with and the message with those bold names removed (any "**<X> Only**. "
+// badge is kept). It returns no names and the message unchanged when the
+// message has no such prefix.
+func parsePlugins(message string) ([]string, string) {
+	match := pluginPrefixPattern.FindStringSubmatchIndex(message)
+	if match == nil {
+		return nil, message
+	}
+
+	names := make([]string, 0)
+	for _, m := range pluginNamePattern.FindAllStringSubmatch(message[match[4]:match[5]], -1) {
+		names = append(names, m[1])
+	}
+
+	return names, message[match[2]:match[3]] + message[match[1]:]
+}
+
+// processEntry process a changelog entry
+func (g *Generator) processEntry(entry *ChangelogEntry) error {
+	ctx, err := g.fetchCommitContext(entry.fileName)
+	if err != nil {
+		var missingPR *MissingPullRequestError
+		var noCommits *utils.NoCommitsFoundError
+		if !errors.As(err, &missingPR) && !errors.As(err, &noCommits) {
+			return fmt.Errorf("failed to fetch commit ctx: %v", err)
+		}
+
+		return &EntryProcessingFailure{
+			FileName:  entry.fileName,
+			CommitSHA: ctx.SHA,
+			Err:       fmt.Errorf("failed to fetch commit ctx: %w", err),
+		}
+	}
+
+	if g.options.Infer {
+		g.inferEntry(entry, ctx)
+	}
+
+	// An entry without a type is grouped under no section of the template,
+	// so it would silently be left out of the notes.
+	if entry.Type == "" {
+		err := errors.New("type is missing")
+		if g.options.Infer {
+			err = fmt.Errorf("type is missing and could not be inferred from the PR title %q", ctx.PrCtx.Title)
+		}
+		return &EntryProcessingFailure{
+			FileName:  entry.fileName,
+			CommitSHA: ctx.SHA,
+			Err:       err,
+		}
+	}
+
+	if entry.Scope == "" {
+		entry.Scope = "Default"
+	}
+
+	// plugins
+	if entry.Scope == pluginScope {
+		plugins, message := parsePlugins(entry.Message)
+		entry.Plugins = plugins
+		if g.options.PluginHeadings != "" {
+			entry.Message = message
+		}
+	}
+
+	// jiras
+	if len(entry.Jiras) == 0 {
+		jiraMap := make(map[string]bool)
+		jiras := utils.MatchJiras(ctx.PrCtx.Body)
+		for _, jira := range jiras {
+			if !jiraMap[jira] {
+				entry.Jiras = append(entry.Jiras, jira)
+				jiraMap[jira] = true
+			}
+		}
+	}
+	if g.options.WithJiras {
+		for _, jiraId := range entry.Jiras {
+			jira := Jira{
+				ID:   jiraId,
+				Link: jiraBaseURL + jiraId,
+			}
+			entry.ParsedJiras = append(entry.ParsedJiras, &jira)
+		}
+	}
+
+	// githubs
+	if len(entry.Githubs) == 0 {
+		entry.Githubs = entry.Prs
+	}
+	if len(entry.Githubs) == 0 {
+		entry.Githubs = append(entry.Githubs, ctx.PrCtx.Number)
+	}
+
+	entry.ParsedGithubs = g.parseGithub(entry.Githubs)
+
+	// pull request; the author's name is resolved for every entry, for the
+	// templates rendering it, not only for the contributors section
+	if ctx.PrCtx.Author.Login != "" {
+		ctx.PrCtx.Author.Name = g.resolver.UserName(ctx.PrCtx.Author.Login)
+	}
+	entry.CommitSHA = ctx.SHA
+	entry.originalCommitSHA = ctx.OriginalSHA
+	entry.SourceBranch = ctx.SourceBranch
+	entry.PullRequest = &ctx.PrCtx
+	entry.Author = &entry.PullRequest.Author
+
+	// badges
+	entry.Badges = g.entryBadges(entry)
+
+	return nil
+}
+
+// matchesEdition reports whether the entry belongs in the output for
+// Options.Edition. Entries without editions apply to every edition.
+func (g *Generator) matchesEdition(entry *ChangelogEntry) bool {
+	if g.options.Edition == "" || len(entry.Editions) == 0 {
+		return true
+	}
+	return contains(entry.Editions, g.options.Edition)
+}
+
+// entryBadges returns the labels rendered as a "**<X> Only**." prefix for an
+// entry restricted to a subset of the configured products or editions. Edition
+// badges are omitted when the output is already filtered to one edition.
+func (g *Generator) entryBadges(entry *ChangelogEntry) []string {
+	badges := make([]string, 0)
+	if restricts(entry.Products, g.config.Products) {
+		badges = append(badges, entry.Products...)
+	}
+	if g.options.Edition == "" && restricts(entry.Editions, g.config.Editions) {
+		badges = append(badges, entry.Editions...)
+	}
+	return badges
+}
+
+func mapKeys(m map[string][]*ChangelogEntry) []string {
+	keys := make([]string, 0)
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,148 @@
+package changelog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRestricts(t *testing.T) {
+	editions := []string{"OSS", "Enterprise"}
+
+	tests := []struct {
+		name    string
+		values  []string
+		allowed []string
+		want    bool
+	}{
+		{name: "no editions", values: nil, allowed: editions, want: false},
+		{name: "every edition", values: []string{"Enterprise", "OSS"}, allowed: editions, want: false},
+		{name: "one edition", values: []string{"Enterprise"}, allowed: editions, want: true},
+		{name: "no editions declared", values: []string{"Enterprise"}, allowed: nil, want: true},
+		{name: "nothing declared or listed", values: nil, allowed: nil, want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := restricts(tc.values, tc.allowed); got != tc.want {
+				t.Fatalf("restricts(%v, %v) = %v, want %v", tc.values, tc.allowed, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchesEdition(t *testing.T) {
+	tests := []struct {
+		name     string
+		editions []string
+		edition  string
+		want     bool
+	}{
+		{name: "no editions, unfiltered", editions: nil, edition: "", want: true},
+		{name: "no editions", editions: nil, edition: "OSS", want: true},
+		{name: "every edition", editions: []string{"OSS", "Enterprise"}, edition: "OSS", want: true},
+		{name: "the filtered edition", editions: []string{"Enterprise"}, edition: "Enterprise", want: true},
+		{name: "another edition", editions: []string{"Enterprise"}, edition: "OSS", want: false},
+		{name: "one edition, unfiltered", editions: []string{"Enterprise"}, edition: "", want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			generator := NewGenerator(Options{Edition: tc.edition}, DefaultConfig(), nil, nil)
+			if got := generator.matchesEdition(&ChangelogEntry{Editions: tc.editions}); got != tc.want {
+				t.Fatalf("matchesEdition(%v) with edition %q = %v, want %v", tc.editions, tc.edition, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEntryBadges(t *testing.T) {
+	config := DefaultConfig()
+	config.Editions = []string{"OSS", "Enterprise"}
+	config.Products = []string{"Gateway", "Konnect"}
+
+	tests := []struct {
+		name     string
+		editions []string
+		products []string
+		edition  string
+		want     []string
+	}{
+		{name: "no editions", want: []string{}},
+		{name: "every edition", editions: []string{"OSS", "Enterprise"}, want: []string{}},
+		{name: "one edition", editions: []string{"Enterprise"}, want: []string{"Enterprise"}},
+		{name: "one edition, filtered to it", editions: []string{"Enterprise"}, edition: "Enterprise", want: []string{}},
+		{name: "one product and one edition", editions: []string{"Enterprise"}, products: []string{"Konnect"}, want: []string{"Konnect", "Enterprise"}},
+		{name: "every product", products: []string{"Gateway", "Konnect"}, want: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			generator := NewGenerator(Options{Edition: tc.edition}, config, nil, nil)
+			entry := &ChangelogEntry{Editions: tc.editions, Products: tc.products}
+			if got := generator.entryBadges(entry); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("entryBadges() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParsePlugins(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		wantNames   []string
+		wantMessage string
+	}{
+		{
+			name:        "one plugin",
+			message:     "**acl**: Fixed a crash.",
+			wantNames:   []string{"acl"},
+			wantMessage: "Fixed a crash.",
+		},
+		{
+			name:        "several plugins",
+			message:     "**kafka-upstream**, **confluent**: Fixed a crash.",
+			wantNames:   []string{"kafka-upstream", "confluent"},
+			wantMessage: "Fixed a crash.",
+		},
+		{
+			name:        "without colon",
+			message:     "**rate-limiting** Fixed a crash.",
+			wantNames:   []string{"rate-limiting"},
+			wantMessage: "Fixed a crash.",
+		},
+		{
+			name:        "badge prefix",
+			message:     "**Konnect Only**. **acl**, **key-auth**: Fixed a crash.",
+			wantNames:   []string{"acl", "key-auth"},
+			wantMessage: "**Konnect Only**. Fixed a crash.",
+		},
+		{
+			name:        "badge without plugin names",
+			message:     "**Konnect Only**. Fixed a crash.",
+			wantNames:   nil,
+			wantMessage: "**Konnect Only**. Fixed a crash.",
+		},
+		{
+			name:        "plain plugin name",
+			message:     "acl: Fixed a crash.",
+			wantNames:   nil,
+			wantMessage: "acl: Fixed a crash.",
+		},
+		{
+			name:        "bold later in the message",
+			message:     "Fixed a crash of **acl**.",
+			wantNames:   nil,
+			wantMessage: "Fixed a crash of **acl**.",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			names, message := parsePlugins(tc.message)
+			if !reflect.DeepEqual(names, tc.wantNames) || message != tc.wantMessage {
+				t.Fatalf("parsePlugins(%q) = %q, %q, want %q, %q", tc.message, names, message, tc.wantNames, tc.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package changelog
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// trailingWhitespacePattern matches the spaces and tabs ending a line.
+var trailingWhitespacePattern = regexp.MustCompile(`(?m)[ \t]+$`)
+
+// unboldedPluginPattern matches the plugin names a Plugin-scope message
+// starts with when they are not in bold, e.g. "acl: " or "`kafka-upstream`,
+// confluent: ", after an optional "**<X> Only**. " badge. The first group
+// captures the badge, the second the plugin names.
+var unboldedPluginPattern = regexp.MustCompile("^((?:\\*\\*[^*\\s][^*]* Only\\*\\*\\. )?)(`?[a-z0-9][a-z0-9-]*`?(?:, ?`?[a-z0-9][a-z0-9-]*`?)*): ")
+
+var pluginListSeparatorPattern = regexp.MustCompile(`, ?`)
+
+// FixFile returns the entry file content with the mistakes ValidateFile
+// reports fixed, where the fix is unambiguous: a key, type, scope, edition or
+// product in the wrong case, a type or scope alias of the inference config
+// (fix for bugfix, core for Core), a PR or issue number given as "#1234",
+// Jira ticket IDs in lower case, Plugin-scope messages not naming the plugins
+// in bold, and trailing whitespace. The fixed values are written over their
+// text in the content (see spliceFixes), so comments, the order of the keys
+// and the style and line breaks of the other values are kept. Content that is
+// not a mapping is returned with only its trailing whitespace removed, leaving
+// the rest to the diagnostics of ValidateFile.
+func (c *Config) FixFile(content []byte) ([]byte, error) {
+	fixed := trailingWhitespacePattern.ReplaceAll(content, nil)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(fixed, &doc); err != nil {
+		return fixed, nil
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fixed, nil
+	}
+
+	parsed := make(map[*yaml.Node]yaml.Node)
+	scalarNodes(doc.Content[0], parsed)
+	if !c.fixMapping(doc.Content[0]) {
+		return fixed, nil
+	}
+
+	fixes := make([]scalarFix, 0)
+	for node, original := range parsed {
+		if node.Value != original.Value || node.Tag != original.Tag || node.Style != original.Style {
+			fixes = append(fixes, scalarFix{node: node, original: original})
+		}
+	}
+	return spliceFixes(fixed, fixes)
+}
+
+// scalarFix is a scalar node fixMapping changed, with the node as parsed.
+type scalarFix struct {
+	node     *yaml.Node
+	original yaml.Node
+}
+
+// scalarNodes records a copy of the scalar nodes under node into nodes.
+func scalarNodes(node *yaml.Node, nodes map[*yaml.Node]yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		nodes[node] = *node
+	}
+	for _, child := range node.Content {
+		scalarNodes(child, nodes)
+	}
+}
+
+// spliceFixes writes the fixed scalars over their text in content, which they
+// were parsed from. A flow scalar is replaced by the encoding of its new
+// value, keeping its quoting style when the value allows it. A block scalar
+// (| or >) only has the start of its first line replaced, so a fix that does
+// not come down to that, or a scalar whose text cannot be located, is left
+// unfixed for ValidateFile to report.
+func spliceFixes(content []byte, fixes []scalarFix) ([]byte, error) {
+	lineStarts := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	type splice struct {
+		start, end  int
+		replacement string
+	}
+	splices := make([]splice, 0, len(fixes))
+	for _, fix := range fixes {
+		original := fix.original
+		if original.Line < 1 || original.Line > len(lineStarts) {
+			continue
+		}
+		start := lineStarts[original.Line-1]
+		for column := 1; column < original.Column && start < len(content); column++ {
+			_, size := utf8.DecodeRune(content[start:])
+			start += size
+		}
+
+		if original.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			if start, end, replacement, ok := blockScalarSplice(content, lineStarts, original, fix.node.Value); ok {
+				splices = append(splices, splice{start: start, end: end, replacement: replacement})
+			}
+			continue
+		}
+
+		end, ok := flowScalarEnd(content, start, original)
+		if !ok {
+			continue
+		}
+		encoded, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: fix.node.Tag, Value: fix.node.Value, Style: fix.node.Style})
+		if err != nil {
+			return nil, err
+		}
+		splices = append(splices, splice{start: start, end: end, replacement: strings.TrimSuffix(string(encoded), "\n")})
+	}
+
+	sort.Slice(splices, func(i, j int) bool { return splices[i].start > splices[j].start })
+	out := content
+	for _, s := range splices {
+		out = append(append(append([]byte{}, out[:s.start]...), s.replacement...), out[s.end:]...)
+	}
+	return out, nil
+}
+
+// flowScalarEnd returns the offset past the text of the plain or quoted
+// scalar starting at start in content, and whether it could be located on
+// that line: the line breaks of a scalar spanning several are not kept.
+func flowScalarEnd(content []byte, start int, node yaml.Node) (int, bool) {
+	if i := bytes.IndexByte(content[start:], '\n'); i >= 0 {
+		content = content[:start+i]
+	}
+	switch node.Style {
+	case yaml.SingleQuotedStyle:
+		for i := start + 1; i < len(content); i++ {
+			if content[i] != '\'' {
+				continue
+			}
+			if i+1 < len(content) && content[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1, true
+		}
+	case yaml.DoubleQuotedStyle:
+		for i := start + 1; i < len(content); i++ {
+			switch content[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, true
+			}
+		}
+	case 0:
+		// A plain scalar on one line is its value.
+		end := start + len(node.Value)
+		if end <= len(content) && string(content[start:end]) == node.Value {
+			return end, true
+		}
+	}
+	return 0, false
+}
+
+// blockScalarSplice returns the start and end of the text to replace in the
+// first line of the block scalar node, parsed from content, and the
+// replacement for its value to become value. It reports false when the values
+// differ beyond the start of that line.
+func blockScalarSplice(content []byte, lineStarts []int, node yaml.Node, value string) (int, int, string, bool) {
+	// The values share their end: what differs is a prefix of each.
+	shared := 0
+	for shared < len(node.Value) && shared < len(value) && node.Value[len(node.Value)-1-shared] == value[len(value)-1-shared] {
+		shared++
+	}
+	oldPrefix, newPrefix := node.Value[:len(node.Value)-shared], value[:len(value)-shared]
+	if strings.Contains(oldPrefix, "\n") || strings.Contains(newPrefix, "\n") {
+		return 0, 0, "", false
+	}
+
+	// The first line of the scalar follows the line of its | or > indicator.
+	if node.Line >= len(lineStarts) {
+		return 0, 0, "", false
+	}
+	start := lineStarts[node.Line]
+	for start < len(content) && content[start] == ' ' {
+		start++
+	}
+	line := content[start:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if len(line) == 0 || !bytes.HasPrefix(line, []byte(oldPrefix)) {
+		return 0, 0, "", false
+	}
+	return start, start + len(oldPrefix), newPrefix, true
+}
+
+// fixMapping fixes the entry mapping node in place, and reports whether it
+// changed anything.
+func (c *Config) fixMapping(node *yaml.Node) bool {
+	changed := false
+
+	keys := make(map[string]bool)
+	for i := 0; i < len(node.Content); i += 2 {
+		keys[node.Content[i].Value] = true
+	}
+	values := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !contains(entryKeys, key.Value) {
+			if match := sameOrAlias(key.Value, entryKeys, nil); match != "" && !keys[match] {
+				keys[match] = true
+				key.Value = match
+				changed = true
+			}
+		}
+		if _, ok := values[key.Value]; !ok {
+			values[key.Value] = value
+		}
+	}
+
+	if value := values["type"]; value != nil {
+		changed = fixLabel(value, c.Types, c.Inference.Types) || changed
+	}
+	if value := values["scope"]; value != nil {
+		changed = fixLabel(value, c.Scopes, c.Inference.TitleScopes) || changed
+	}
+	if value := values["message"]; value != nil {
+		changed = fixMessage(value, values["scope"]) || changed
+	}
+	for _, field := range []string{"prs", "githubs"} {
+		if value := values[field]; value != nil && value.Kind == yaml.SequenceNode {
+			for _, item := range value.Content {
+				changed = fixNumber(item) || changed
+			}
+		}
+	}
+	if value := values["jiras"]; value != nil && value.Kind == yaml.SequenceNode {
+		for _, item := range value.Content {
+			changed = fixJira(item) || changed
+		}
+	}
+	if value := values["editions"]; value != nil && value.Kind == yaml.SequenceNode {
+		for _, item := range value.Content {
+			changed = fixLabel(item, c.Editions, nil) || changed
+		}
+	}
+	if value := values["products"]; value != nil && value.Kind == yaml.SequenceNode {
+		for _, item := range value.Content {
+			changed = fixLabel(item, c.Products, nil) || changed
+		}
+	}
+
+	return changed
+}
+
+// fixLabel replaces a value that is not allowed with the allowed one it equals
+// but for case, or that aliases maps it to.
+func fixLabel(node *yaml.Node, allowed []string, aliases map[string]string) bool {
+	if !isString(node) || contains(allowed, node.Value) {
+		return false
+	}
+	match := sameOrAlias(node.Value, allowed, aliases)
+	if match == "" {
+		return false
+	}
+	node.Value = match
+	return true
+}
+
+// fixMessage trims the trailing whitespace of the message lines, and puts in
+// bold the plugin names a Plugin-scope message starts with.
+func fixMessage(node, scope *yaml.Node) bool {
+	if !isString(node) {
+		return false
+	}
+	message := trailingWhitespacePattern.ReplaceAllString(node.Value, "")
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		message = strings.TrimRight(message, " \t\n")
+	}
+
+	if scope != nil && scope.Value == pluginScope && !pluginPrefixPattern.MatchString(message) {
+		if match := unboldedPluginPattern.FindStringSubmatchIndex(message); match != nil {
+			names := pluginListSeparatorPattern.Split(message[match[4]:match[5]], -1)
+			for i, name := range names {
+				names[i] = "**" + strings.Trim(name, "`") + "**"
+			}
+			message = message[match[2]:match[3]] + strings.Join(names, ", ") + ": " + message[match[1]:]
+		}
+	}
+
+	if message == node.Value {
+		return false
+	}
+	node.Value = message
+	return true
+}
+
+// fixNumber turns a PR or issue number given as a string, such as "#1234",
+// into a number.
+func fixNumber(node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+		return false
+	}
+	no := strings.TrimPrefix(strings.TrimSpace(node.Value), "#")
+	if _, err := strconv.Atoi(no); err != nil {
+		return false
+	}
+	node.Value, node.Tag, node.Style = no, "!!int", 0
+	return true
+}
+
+// fixJira upper-cases a Jira ticket ID, e.g. fti-1234.
+func fixJira(node *yaml.Node) bool {
+	if !isString(node) || jiraPattern.MatchString(node.Value) {
+		return false
+	}
+	id := strings.ToUpper(strings.TrimSpace(node.Value))
+	if !jiraPattern.MatchString(id) {
+		return false
+	}
+	node.Value = id
+	return true
+}
@@ -0,0 +1,87 @@
+package changelog
+
+import "testing"
+
+func TestFixFile(t *testing.T) {
+	config := DefaultConfig()
+	config.Editions = []string{"OSS", "Enterprise"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "valid",
+			content: "message: Fixed an issue\ntype: bugfix\nprs: [\"#12\"] # the backport\n",
+			want:    "message: Fixed an issue\ntype: bugfix\nprs: [12] # the backport\n",
+		},
+		{
+			name:    "case and aliases",
+			content: "# core fix\nType: Fix\nscope: core\nmessage: Fixed an issue\neditions:\n  - enterprise\n",
+			want:    "# core fix\ntype: bugfix\nscope: Core\nmessage: Fixed an issue\neditions:\n  - Enterprise\n",
+		},
+		{
+			name:    "plugin names",
+			content: "message: \"acl, `key-auth`: Fixed an issue. \"\ntype: bugfix\nscope: Plugin\n",
+			want:    "message: \"**acl**, **key-auth**: Fixed an issue.\"\ntype: bugfix\nscope: Plugin\n",
+		},
+		{
+			name:    "lower case jira",
+			content: "message: Fixed an issue\ntype: bugfix\njiras: [fti-12, FTI-3]\n",
+			want:    "message: Fixed an issue\ntype: bugfix\njiras: [FTI-12, FTI-3]\n",
+		},
+		{
+			name:    "trailing whitespace only",
+			content: "message: |\n  Fixed an issue.  \ntype: bugfix   \n",
+			want:    "message: |\n  Fixed an issue.\ntype: bugfix\n",
+		},
+		{
+			name:    "folded message",
+			content: "message: >\n  acl: Fixed a thing\n  across lines.\ntype: fix\n",
+			want:    "message: >\n  acl: Fixed a thing\n  across lines.\ntype: bugfix\n",
+		},
+		{
+			name:    "folded plugin message",
+			content: "message: >\n  acl: Fixed a thing\n  across lines.\ntype: fix\nscope: Plugin\n",
+			want:    "message: >\n  **acl**: Fixed a thing\n  across lines.\ntype: bugfix\nscope: Plugin\n",
+		},
+		{
+			name:    "literal plugin message",
+			content: "message: |-\n  `acl`, key-auth: Fixed a thing.\n\n  Across paragraphs.\nscope: Plugin # per plugin\ntype: bugfix\n",
+			want:    "message: |-\n  **acl**, **key-auth**: Fixed a thing.\n\n  Across paragraphs.\nscope: Plugin # per plugin\ntype: bugfix\n",
+		},
+		{
+			name:    "multi-line quoted message",
+			content: "message: \"acl: Fixed\n  a thing.\"\ntype: fix\nscope: Plugin\n",
+			want:    "message: \"acl: Fixed\n  a thing.\"\ntype: bugfix\nscope: Plugin\n",
+		},
+		{
+			name:    "ambiguous",
+			content: "message: Fixed an issue\ntype: bugfx\nscope: Clustring\n",
+			want:    "message: Fixed an issue\ntype: bugfx\nscope: Clustring\n",
+		},
+		{
+			name:    "key already present",
+			content: "message: Fixed an issue\ntype: bugfix\nscope: Core\nScope: PDK\n",
+			want:    "message: Fixed an issue\ntype: bugfix\nscope: Core\nScope: PDK\n",
+		},
+		{
+			name:    "invalid YAML",
+			content: "message: Fixed \ntype: [bugfix\n",
+			want:    "message: Fixed\ntype: [bugfix\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := config.FixFile([]byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("FixFile() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package changelog
+
+// ForwardPortGap is an entry on the source branch whose change is missing
+// from some of the target branches.
+type ForwardPortGap struct {
+	Entry   BranchEntry
+	Missing []string
+}
+
+// FindForwardPortGaps lists the entries under changelogPaths on source whose
+// change is missing from some of targets.
+func (f *ProvenanceFinder) FindForwardPortGaps(source string, targets, changelogPaths []string) ([]ForwardPortGap, error) {
+	entries, err := f.listBranchEntries(source, changelogPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(entries)
+	gaps := make([]ForwardPortGap, 0)
+	for i, entry := range entries {
+		f.logger.Info("checking changelog file", "file", entry.File, "sha", entry.Commit, "index", i+1, "total", total)
+
+		missing := make([]string, 0)
+		for _, target := range targets {
+			presence, ok := f.findOnBranch(target, entry.Commit, entry.File)
+			if !ok {
+				missing = append(missing, target)
+				continue
+			}
+			f.logger.Debug("change found on branch", "file", entry.File, "branch", target, "sha", presence.Commit, "via", presence.Via)
+		}
+
+		if len(missing) > 0 {
+			gaps = append(gaps, ForwardPortGap{Entry: entry, Missing: missing})
+		}
+	}
+	return gaps, nil
+}
@@ -0,0 +1,384 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Kong/changelog/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Generator collects and renders the changelog entries selected by its
+// Options. The state of a run (the shipped, reverted and inferred entries
+// found) is kept until the next Collect; a Generator is not safe for
+// concurrent use.
+type Generator struct {
+	options  Options
+	config   Config
+	resolver PullRequestResolver
+	logger   *slog.Logger
+
+	// shipped is set when options.Since is, for the duration of Collect.
+	shipped        *shippedIndex
+	shippedEntries []SkippedEntry
+
+	revertedEntries []SkippedEntry
+
+	duplicateEntries []SkippedEntry
+
+	// collectedFiles are the entry files in the notes of the last Collect,
+	// relative to options.RepoPath (see Collected).
+	collectedFiles []string
+
+	// inferredEntries are the entries with inferred fields, sorted by file name.
+	inferredEntries []*ChangelogEntry
+
+	// attribution locates entries on options.SourceBranches and answers their
+	// history lookups from one index per ref; set by Collect.
+	attribution *ProvenanceFinder
+}
+
+// NewGenerator returns a Generator of the entries selected by options,
+// validated against config and attributed through resolver. A nil logger
+// discards the logs.
+func NewGenerator(options Options, config Config, resolver PullRequestResolver, logger *slog.Logger) *Generator {
+	if logger == nil {
+		logger = discardLogger()
+	}
+	if options.ScopePriority == nil {
+		options.ScopePriority = DefaultScopePriority()
+	}
+	return &Generator{
+		options:  options,
+		config:   config,
+		resolver: resolver,
+		logger:   logger,
+	}
+}
+
+// Close stops the git processes Collect keeps open to read the repository.
+// The Generator can still be used afterwards; they are restarted on demand.
+func (g *Generator) Close() error {
+	return utils.CloseRepository(g.options.RepoPath)
+}
+
+func (g *Generator) logEntryProcessingFailure(failure EntryProcessingFailure) {
+	attrs := []any{"file", failure.FileName}
+	if failure.CommitSHA != "" {
+		attrs = append(attrs, "sha", failure.CommitSHA)
+	}
+	g.logger.Warn("skipping changelog entry", append(attrs, "error", failure.Err)...)
+}
+
+func writeEntryProcessingSummary(w io.Writer, failures []EntryProcessingFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	entryNoun := "entries"
+	if len(failures) == 1 {
+		entryNoun = "entry"
+	}
+
+	fmt.Fprintf(w, "\nskipped %d changelog %s:\n", len(failures), entryNoun)
+	for i, failure := range failures {
+		fmt.Fprintf(w, "%d. %s\n", i+1, strings.ReplaceAll(failure.Error(), "\n", "\n   "))
+	}
+}
+
+// WriteSummary writes the report of the last Collect to w: the entries
+// already shipped, reverted, duplicated, with inferred fields, and the
+// failures skipped.
+func (g *Generator) WriteSummary(w io.Writer, failures []EntryProcessingFailure) {
+	g.writeShippedSummary(w)
+	g.writeRevertedSummary(w)
+	g.writeDuplicateSummary(w)
+	g.writeInferenceSummary(w)
+	writeEntryProcessingSummary(w, failures)
+}
+
+// collectEntry parses the entry file content read from filePath, processes it
+// and adds it to maps. A failure is returned for an entry that must be skipped
+// and an error when collecting cannot continue.
+func (g *Generator) collectEntry(filePath string, content []byte, maps map[string]map[string][]*ChangelogEntry) (*EntryProcessingFailure, error) {
+	// parse entry
+	entry := &ChangelogEntry{}
+	err := yaml.Unmarshal(content, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML from %s: %v", filepath.Base(filePath), err)
+	}
+
+	entry.fileName = filePath
+
+	diagnostics := g.config.ValidateFile(filePath, content)
+	if !g.options.Strict {
+		diagnostics = configDiagnostics(diagnostics)
+	}
+	if len(diagnostics) > 0 {
+		return &EntryProcessingFailure{FileName: filePath, Err: &ValidationError{Diagnostics: diagnostics}}, nil
+	}
+
+	if !g.matchesEdition(entry) {
+		g.logger.Debug("skipping file", "file", filePath, "reason", "not in edition "+g.options.Edition)
+		return nil, nil
+	}
+
+	if g.shipped != nil {
+		if reason := g.shippedReason(g.shipped, filePath, content); reason != "" {
+			g.shippedEntries = append(g.shippedEntries, SkippedEntry{FileName: filePath, Reason: reason})
+			if g.options.Shipped != ShippedFlag {
+				g.logger.Debug("skipping file", "file", filePath, "reason", "already shipped: "+reason)
+				return nil, nil
+			}
+			entry.ShippedIn = g.options.Since
+		}
+	}
+
+	err = g.processEntry(entry)
+	if err != nil {
+		failure := entryProcessingFailureFromError(err, filePath)
+		if failure == nil {
+			return nil, fmt.Errorf("failed to process entry: %v", err)
+		}
+		return failure, nil
+	}
+
+	if revert, reason := g.revertedBy(entry); revert != "" {
+		g.revertedEntries = append(g.revertedEntries, SkippedEntry{FileName: filePath, Reason: reason})
+		if !g.keepsReverted() {
+			g.logger.Debug("skipping file", "file", filePath, "sha", entry.CommitSHA, "pr", entry.PullRequest.Number, "reason", "reverted: "+reason)
+			return nil, nil
+		}
+		if g.options.Reverted == RevertedNote {
+			entry.Reverted = revert
+		}
+	}
+
+	g.logger.Debug("collected changelog entry", "file", filePath, "sha", entry.CommitSHA, "pr", entry.PullRequest.Number, "type", entry.Type, "scope", entry.Scope)
+	if maps[entry.Type] == nil {
+		maps[entry.Type] = make(map[string][]*ChangelogEntry)
+	}
+	maps[entry.Type][entry.Scope] = append(maps[entry.Type][entry.Scope], entry)
+	g.collectedFiles = append(g.collectedFiles, g.relativePath(filePath))
+	return nil, nil
+}
+
+// Collected returns the entry files the last Collect put in the notes,
+// including the duplicates merged into another entry, relative to the
+// repository path. The entries left out (failed, of another edition, already
+// shipped or reverted) are not.
+func (g *Generator) Collected() []string {
+	return g.collectedFiles
+}
+
+func (g *Generator) collectFromFolder(changelogPath string, maps map[string]map[string][]*ChangelogEntry) ([]EntryProcessingFailure, error) {
+	failures := make([]EntryProcessingFailure, 0)
+	changelogPath = filepath.Join(g.options.RepoPath, changelogPath)
+	exists, err := utils.DirExists(changelogPath)
+	if !exists {
+		return failures, err
+	}
+
+	files, err := os.ReadDir(changelogPath)
+	if err != nil {
+		return failures, err
+	}
+	total := len(files)
+	g.logger.Info("reading files from folder", "folder", changelogPath)
+	for i := 1; i <= total; i++ {
+		file := files[i-1]
+		if file.IsDir() {
+			continue
+		}
+
+		if !isYAML(file.Name()) {
+			g.logger.Debug("skipping file", "file", file.Name(), "reason", "not YAML", "index", i, "total", total)
+			continue
+		}
+
+		filePath := filepath.Join(changelogPath, file.Name())
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return failures, err
+		}
+
+		g.logger.Info("processing changelog file", "file", file.Name(), "index", i, "total", total)
+
+		failure, err := g.collectEntry(filePath, content, maps)
+		if err != nil {
+			return failures, err
+		}
+		if failure != nil {
+			g.logEntryProcessingFailure(*failure)
+			failures = append(failures, *failure)
+		}
+	}
+
+	return failures, nil
+}
+
+// collectFromRange collects the entry files under options.ChangelogPaths that
+// were added or modified between options.FromRef and options.ToRef, reading
+// them as of options.ToRef so that no checkout is needed.
+func (g *Generator) collectFromRange(maps map[string]map[string][]*ChangelogEntry) ([]EntryProcessingFailure, error) {
+	failures := make([]EntryProcessingFailure, 0)
+	files, err := utils.FilesChangedBetween(g.options.RepoPath, g.options.FromRef, g.options.ToRef, g.options.ChangelogPaths)
+	if err != nil {
+		return failures, err
+	}
+
+	total := len(files)
+	g.logger.Info("reading files changed between refs", "from", g.options.FromRef, "to", g.options.ToRef)
+	for i := 1; i <= total; i++ {
+		file := files[i-1]
+		if !isYAML(file) {
+			g.logger.Debug("skipping file", "file", file, "reason", "not YAML", "index", i, "total", total)
+			continue
+		}
+
+		content, err := utils.ReadFileAt(g.options.RepoPath, g.options.ToRef, file)
+		if err != nil {
+			return failures, err
+		}
+
+		g.logger.Info("processing changelog file", "file", file, "index", i, "total", total)
+
+		filePath := filepath.Join(g.options.RepoPath, file)
+		failure, err := g.collectEntry(filePath, content, maps)
+		if err != nil {
+			return failures, err
+		}
+		if failure != nil {
+			g.logEntryProcessingFailure(*failure)
+			failures = append(failures, *failure)
+		}
+	}
+
+	return failures, nil
+}
+
+// Collect reads, validates and attributes the selected entries, reports (or
+// merges) the duplicates and groups them by type and scope. Entries that
+// cannot be processed are skipped and returned as failures; the error is set
+// when collecting cannot continue.
+func (g *Generator) Collect() (*TemplateData, []EntryProcessingFailure, error) {
+	maps := make(map[string]map[string][]*ChangelogEntry)
+	failures := make([]EntryProcessingFailure, 0)
+
+	g.shipped, g.shippedEntries = nil, nil
+	g.revertedEntries, g.inferredEntries = nil, nil
+	g.duplicateEntries, g.collectedFiles = nil, nil
+	g.attribution = NewProvenanceFinder(g.options.RepoPath, g.options.ToRef, g.options.PatchID, g.options.ChangelogPaths, g.logger)
+	if g.options.Since != "" {
+		idx, err := loadShippedIndex(g.options.RepoPath, g.options.Since)
+		if err != nil {
+			return nil, failures, err
+		}
+		g.shipped = idx
+	}
+
+	if g.options.FromRef != "" {
+		rangeFailures, err := g.collectFromRange(maps)
+		failures = append(failures, rangeFailures...)
+		if err != nil {
+			return nil, failures, err
+		}
+	} else {
+		for _, path := range g.options.ChangelogPaths {
+			folderFailures, err := g.collectFromFolder(path, maps)
+			failures = append(failures, folderFailures...)
+			if err != nil {
+				return nil, failures, err
+			}
+		}
+	}
+
+	g.findDuplicates(maps)
+
+	data := &TemplateData{
+		Title: g.options.Title,
+		Type:  make(map[string][]ScopeEntries),
+	}
+
+	for t, scopeEntries := range maps {
+		scopes := mapKeys(scopeEntries)
+		sort.Slice(scopes, func(i, j int) bool {
+			scopei := scopes[i]
+			scopej := scopes[j]
+			return g.options.ScopePriority[scopei] < g.options.ScopePriority[scopej]
+		})
+
+		list := make([]ScopeEntries, 0)
+		for _, scope := range scopes {
+			entries := ScopeEntries{
+				ScopeName: scope,
+				Entries:   scopeEntries[scope],
+			}
+			if scope == pluginScope && g.options.PluginHeadings != "" {
+				entries.Groups = g.groupByPlugin(entries.Entries)
+			}
+			list = append(list, entries)
+		}
+		data.Type[t] = list
+	}
+
+	if g.options.WithContributors {
+		data.Contributors = g.collectContributors(maps)
+	}
+
+	if g.options.Infer {
+		g.inferredEntries = inferredEntries(maps)
+	}
+
+	return data, failures, nil
+}
+
+// groupByPlugin splits Plugin-scope entries into one group per plugin, sorted
+// by name. An entry naming several plugins is listed under each of them, or
+// under a single combined heading with PluginHeadingsCombined. Entries naming
+// no plugin go into an unnamed group, which sorts first.
+func (g *Generator) groupByPlugin(entries []*ChangelogEntry) []GroupEntries {
+	groups := make(map[string][]*ChangelogEntry)
+	for _, entry := range entries {
+		if len(entry.Plugins) == 0 || g.options.PluginHeadings == PluginHeadingsCombined {
+			name := strings.Join(entry.Plugins, ", ")
+			groups[name] = append(groups[name], entry)
+			continue
+		}
+		for _, plugin := range entry.Plugins {
+			groups[plugin] = append(groups[plugin], entry)
+		}
+	}
+
+	names := mapKeys(groups)
+	sort.Strings(names)
+
+	list := make([]GroupEntries, 0, len(names))
+	for _, name := range names {
+		list = append(list, GroupEntries{
+			Name:    name,
+			Entries: groups[name],
+		})
+	}
+	return list
+}
+
+// Generate collects the entries, writes the summary of the run to summary
+// and renders the changelog to w.
+func (g *Generator) Generate(w, summary io.Writer) error {
+	g.logger.Debug("generating changelog", "options", fmt.Sprintf("%+v", g.options))
+
+	data, failures, err := g.Collect()
+	g.WriteSummary(summary, failures)
+	if err != nil {
+		return err
+	}
+
+	return g.Render(w, data)
+}
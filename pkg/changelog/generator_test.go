@@ -0,0 +1,395 @@
+package changelog
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v failed: %v", args, err)
+	}
+	return string(out)
+}
+
+// commitFile writes path (creating parent dirs) with content, commits it, and
+// returns the new SHA.
+func commitFile(t *testing.T, dir, path, content, msg string) string {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", path)
+	runGit(t, dir, "commit", "--no-gpg-sign", "-m", msg)
+	return strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+}
+
+// fakeResolver resolves the commits it maps to a PR, and nothing else.
+type fakeResolver map[string]*PullRequestContext
+
+func (r fakeResolver) MergedPullRequest(commit string) (*PullRequestContext, error) {
+	return r[commit], nil
+}
+
+func (r fakeResolver) PullRequest(number int) (*PullRequestContext, error) {
+	for _, pr := range r {
+		if pr.Number == number {
+			return pr, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r fakeResolver) UserName(string) string {
+	return ""
+}
+
+func TestGeneratorWithoutGitHub(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	fixed := commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: Fixed a crash.\ntype: bugfix\nscope: Core\n", "fix: crash (#10)")
+	commitFile(t, dir, "changelog/unreleased/kong/orphan.yml", "message: Orphan.\ntype: feature\n", "no PR")
+
+	resolver := fakeResolver{
+		fixed: {Number: 10, Title: "fix: crash", MergedAt: time.Now(), MergeCommitSHA: fixed},
+	}
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+	}, DefaultConfig(), resolver, nil)
+
+	data, failures, err := generator.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || filepath.Base(failures[0].FileName) != "orphan.yml" {
+		t.Fatalf("failures = %+v, want orphan.yml only", failures)
+	}
+
+	scopes := data.Type["bugfix"]
+	if len(scopes) != 1 || scopes[0].ScopeName != "Core" || len(scopes[0].Entries) != 1 {
+		t.Fatalf("bugfix scopes = %+v, want one Core entry", scopes)
+	}
+	if entry := scopes[0].Entries[0]; entry.CommitSHA != fixed || entry.PullRequest.Number != 10 {
+		t.Fatalf("entry attributed to %s / #%d, want %s / #10", entry.CommitSHA, entry.PullRequest.Number, fixed)
+	}
+
+	var out bytes.Buffer
+	if err := generator.Render(&out, data); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Fixed a crash.") || strings.Contains(out.String(), "Orphan.") {
+		t.Fatalf("rendered changelog:\n%s", out.String())
+	}
+}
+
+func TestGeneratorSourceBranchHops(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	base := commitFile(t, dir, "README.md", "base\n", "init")
+	fixEntry := "changelog/unreleased/kong/fix.yml"
+	featEntry := "changelog/unreleased/kong/feat.yml"
+
+	// Upstream changes land on master, are synced into EE, backported from
+	// EE to the minor branch, and from there to the fix-release branch.
+	upstreamFix := commitFile(t, dir, fixEntry, "message: Fixed a crash.\ntype: bugfix\n", "fix: crash (#1)")
+	upstreamFeat := commitFile(t, dir, featEntry, "message: Added a flag.\ntype: feature\n", "feat: flag (#2)")
+
+	runGit(t, dir, "checkout", "-q", "-b", "ee", base)
+	runGit(t, dir, "cherry-pick", "-x", upstreamFix)
+	eeFix := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "cherry-pick", "-x", upstreamFeat)
+	eeFeat := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	runGit(t, dir, "checkout", "-q", "-b", "minor", base)
+	runGit(t, dir, "cherry-pick", "-x", eeFix)
+	minorFix := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	// The feature skips the minor branch: the nearer hop lacks the commit.
+	runGit(t, dir, "checkout", "-q", "-b", "fix-release", base)
+	runGit(t, dir, "cherry-pick", "-x", minorFix)
+	runGit(t, dir, "cherry-pick", "-x", eeFeat)
+
+	resolver := fakeResolver{
+		upstreamFix:  {Number: 1, Title: "fix: crash", MergedAt: time.Now(), MergeCommitSHA: upstreamFix},
+		upstreamFeat: {Number: 2, Title: "feat: flag", MergedAt: time.Now(), MergeCommitSHA: upstreamFeat},
+		eeFix:        {Number: 11, Title: "sync: crash", MergedAt: time.Now(), MergeCommitSHA: eeFix},
+		eeFeat:       {Number: 12, Title: "sync: flag", MergedAt: time.Now(), MergeCommitSHA: eeFeat},
+		minorFix:     {Number: 21, Title: "backport: crash", MergedAt: time.Now(), MergeCommitSHA: minorFix},
+	}
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+		SourceBranches:  []string{"minor", "ee"},
+	}, DefaultConfig(), resolver, nil)
+
+	data, failures, err := generator.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("failures = %+v, want none", failures)
+	}
+
+	tests := []struct {
+		name   string
+		typ    string
+		commit string
+		pr     int
+		branch string
+	}{
+		{name: "nearest hop wins", typ: "bugfix", commit: minorFix, pr: 21, branch: "minor"},
+		{name: "outer hop when the nearer one lacks the commit", typ: "feature", commit: eeFeat, pr: 12, branch: "ee"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scopes := data.Type[tc.typ]
+			if len(scopes) != 1 || len(scopes[0].Entries) != 1 {
+				t.Fatalf("%s scopes = %+v, want one entry", tc.typ, scopes)
+			}
+			entry := scopes[0].Entries[0]
+			if entry.CommitSHA != tc.commit || entry.PullRequest.Number != tc.pr || entry.SourceBranch != tc.branch {
+				t.Fatalf("entry attributed to %s / #%d on %q, want %s / #%d on %q",
+					entry.CommitSHA, entry.PullRequest.Number, entry.SourceBranch, tc.commit, tc.pr, tc.branch)
+			}
+		})
+	}
+}
+
+func TestGeneratorRange(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	kept := commitFile(t, dir, "changelog/unreleased/kong/kept.yml", "message: Kept as is.\ntype: bugfix\n", "fix: kept (#1)")
+	modified := commitFile(t, dir, "changelog/unreleased/kong/modified.yml", "message: Fixed a crash.\ntype: bugfix\n", "fix: crash (#2)")
+	deleted := commitFile(t, dir, "changelog/unreleased/kong/deleted.yml", "message: Dropped later.\ntype: feature\n", "feat: dropped (#3)")
+	runGit(t, dir, "tag", "3.14.0.8")
+
+	// Between the refs, an entry is added, one reworded and one deleted; the
+	// changes after --to are not read.
+	added := commitFile(t, dir, "changelog/unreleased/kong/added.yml", "message: Added a flag.\ntype: feature\n", "feat: flag (#4)")
+	commitFile(t, dir, "changelog/unreleased/kong/modified.yml", "message: Fixed a crash on start.\ntype: bugfix\n", "docs: reword (#5)")
+	runGit(t, dir, "rm", "-q", "changelog/unreleased/kong/deleted.yml")
+	runGit(t, dir, "commit", "-q", "--no-gpg-sign", "-m", "drop (#6)")
+	runGit(t, dir, "tag", "3.14.0.9")
+	commitFile(t, dir, "changelog/unreleased/kong/modified.yml", "message: Fixed a crash after the release.\ntype: bugfix\n", "docs: reword again (#7)")
+	commitFile(t, dir, "changelog/unreleased/kong/late.yml", "message: Too late.\ntype: bugfix\n", "fix: late (#8)")
+
+	resolver := fakeResolver{
+		kept:     {Number: 1, Title: "fix: kept", MergedAt: time.Now(), MergeCommitSHA: kept},
+		modified: {Number: 2, Title: "fix: crash", MergedAt: time.Now(), MergeCommitSHA: modified},
+		deleted:  {Number: 3, Title: "feat: dropped", MergedAt: time.Now(), MergeCommitSHA: deleted},
+		added:    {Number: 4, Title: "feat: flag", MergedAt: time.Now(), MergeCommitSHA: added},
+	}
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+		FromRef:         "3.14.0.8",
+		ToRef:           "3.14.0.9",
+	}, DefaultConfig(), resolver, nil)
+
+	data, failures, err := generator.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("failures = %+v, want none", failures)
+	}
+
+	got := make(map[string]*ChangelogEntry)
+	for _, scopes := range data.Type {
+		for _, scope := range scopes {
+			for _, entry := range scope.Entries {
+				got[filepath.Base(entry.fileName)] = entry
+			}
+		}
+	}
+	tests := []struct {
+		file    string
+		message string
+		pr      int
+	}{
+		{file: "added.yml", message: "Added a flag.", pr: 4},
+		// Read as of --to, and attributed to the commit that added it.
+		{file: "modified.yml", message: "Fixed a crash on start.", pr: 2},
+	}
+	if len(got) != len(tests) {
+		t.Fatalf("collected %v, want added.yml and modified.yml", got)
+	}
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			entry := got[tc.file]
+			if entry == nil {
+				t.Fatalf("%s not collected", tc.file)
+			}
+			if entry.Message != tc.message || entry.PullRequest.Number != tc.pr {
+				t.Fatalf("%s = %q / #%d, want %q / #%d", tc.file, entry.Message, entry.PullRequest.Number, tc.message, tc.pr)
+			}
+		})
+	}
+}
+
+func TestGroupByPlugin(t *testing.T) {
+	acl := &ChangelogEntry{Message: "Fixed a crash.", Plugins: []string{"acl"}}
+	both := &ChangelogEntry{Message: "Fixed a leak.", Plugins: []string{"kafka-upstream", "acl"}}
+	badged := &ChangelogEntry{Message: "**Konnect Only**. Added a flag.", Plugins: []string{"key-auth"}}
+	unnamed := &ChangelogEntry{Message: "Fixed the plugin iterator.", Plugins: nil}
+	entries := []*ChangelogEntry{acl, both, badged, unnamed}
+
+	tests := []struct {
+		mode string
+		want []GroupEntries
+	}{
+		{
+			mode: PluginHeadingsEach,
+			want: []GroupEntries{
+				{Name: "", Entries: []*ChangelogEntry{unnamed}},
+				{Name: "acl", Entries: []*ChangelogEntry{acl, both}},
+				{Name: "kafka-upstream", Entries: []*ChangelogEntry{both}},
+				{Name: "key-auth", Entries: []*ChangelogEntry{badged}},
+			},
+		},
+		{
+			mode: PluginHeadingsCombined,
+			want: []GroupEntries{
+				{Name: "", Entries: []*ChangelogEntry{unnamed}},
+				{Name: "acl", Entries: []*ChangelogEntry{acl}},
+				{Name: "kafka-upstream, acl", Entries: []*ChangelogEntry{both}},
+				{Name: "key-auth", Entries: []*ChangelogEntry{badged}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.mode, func(t *testing.T) {
+			generator := NewGenerator(Options{PluginHeadings: tc.mode}, DefaultConfig(), nil, nil)
+			got := generator.groupByPlugin(entries)
+			if len(got) != len(tc.want) {
+				t.Fatalf("groupByPlugin() = %+v, want %+v", got, tc.want)
+			}
+			for i, group := range tc.want {
+				if got[i].Name != group.Name || !reflect.DeepEqual(got[i].Entries, group.Entries) {
+					t.Fatalf("group %d = %q %+v, want %q %+v", i, got[i].Name, got[i].Entries, group.Name, group.Entries)
+				}
+			}
+		})
+	}
+}
+
+// recordingResolver is a fakeResolver recording the commits it is asked the
+// merged PR of.
+type recordingResolver struct {
+	fakeResolver
+	merged []string
+}
+
+func (r *recordingResolver) MergedPullRequest(commit string) (*PullRequestContext, error) {
+	r.merged = append(r.merged, commit)
+	return r.fakeResolver.MergedPullRequest(commit)
+}
+
+func TestGeneratorMergeCommitAttribution(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	commitFile(t, dir, "README.md", "base\n", "init")
+	main := strings.TrimSpace(runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+
+	// A PR merged with "Create a merge commit", which the resolver does not
+	// list for the commit of its branch.
+	runGit(t, dir, "checkout", "-q", "-b", "someone/fix")
+	fix := commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: Fixed a crash.\ntype: bugfix\n", "fix a crash")
+	runGit(t, dir, "checkout", "-q", main)
+	runGit(t, dir, "merge", "-q", "--no-ff", "--no-gpg-sign", "-m", "Merge pull request #7 from someone/fix", "someone/fix")
+	prMerge := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	// A branch merged locally, whose commit the resolver knows.
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	feature := commitFile(t, dir, "changelog/unreleased/kong/feat.yml", "message: Added a flag.\ntype: feature\n", "add a flag")
+	runGit(t, dir, "checkout", "-q", main)
+	runGit(t, dir, "merge", "-q", "--no-ff", "--no-gpg-sign", "-m", "Merge branch 'feature'", "feature")
+
+	// Squash-merged upstream PRs synced in by a merge PR: the one the
+	// resolver knows keeps its own PR, the other one is not credited to the
+	// sync PR.
+	runGit(t, dir, "checkout", "-q", "-b", "sync-master")
+	squashed := commitFile(t, dir, "changelog/unreleased/kong/perf.yml", "message: Sped up the router.\ntype: performance\n", "perf(core): router (#100)")
+	unknown := commitFile(t, dir, "changelog/unreleased/kong/dep.yml", "message: Bumped OpenSSL.\ntype: dependency\n", "chore(deps): bump OpenSSL (#101)")
+	runGit(t, dir, "checkout", "-q", main)
+	runGit(t, dir, "merge", "-q", "--no-ff", "--no-gpg-sign", "-m", "Merge pull request #200 from Kong/sync-master", "sync-master")
+	syncMerge := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	resolver := &recordingResolver{fakeResolver: fakeResolver{
+		prMerge:   {Number: 7, Title: "fix: crash", MergedAt: time.Now(), MergeCommitSHA: prMerge},
+		feature:   {Number: 8, Title: "feat: flag", MergedAt: time.Now(), MergeCommitSHA: feature},
+		squashed:  {Number: 100, Title: "perf(core): router", MergedAt: time.Now(), MergeCommitSHA: squashed},
+		syncMerge: {Number: 200, Title: "chore: sync master", MergedAt: time.Now(), MergeCommitSHA: syncMerge},
+	}}
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+	}, DefaultConfig(), resolver, nil)
+
+	data, failures, err := generator.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || filepath.Base(failures[0].FileName) != "dep.yml" {
+		t.Fatalf("failures = %+v, want dep.yml only", failures)
+	}
+
+	tests := []struct {
+		name string
+		typ  string
+		pr   int
+	}{
+		{name: "merge commit subject when the resolver finds nothing", typ: "bugfix", pr: 7},
+		{name: "resolver first", typ: "feature", pr: 8},
+		{name: "squash commit synced by a merge PR", typ: "performance", pr: 100},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			scopes := data.Type[tc.typ]
+			if len(scopes) != 1 || len(scopes[0].Entries) != 1 {
+				t.Fatalf("%s scopes = %+v, want one entry", tc.typ, scopes)
+			}
+			if pr := scopes[0].Entries[0].PullRequest; pr == nil || pr.Number != tc.pr {
+				t.Fatalf("entry attributed to %+v, want #%d", pr, tc.pr)
+			}
+		})
+	}
+
+	// Every commit is asked of the resolver first.
+	asked := strings.Join(resolver.merged, " ")
+	for _, commit := range []string{fix, feature, squashed, unknown} {
+		if !strings.Contains(asked, commit) {
+			t.Fatalf("MergedPullRequest called with %v, want %s among them", resolver.merged, commit)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/google/go-github/v56/github"
+)
+
+var pullRequestRefPattern = regexp.MustCompile(`\(#(\d+)\)`)
+
+// PullRequestRefs returns the PR numbers referenced as "(#1234)" in text, in
+// order of appearance.
+func PullRequestRefs(text string) []int {
+	prs := make([]int, 0)
+	for _, match := range pullRequestRefPattern.FindAllStringSubmatch(text, -1) {
+		if no, err := strconv.Atoi(match[1]); err == nil {
+			prs = append(prs, no)
+		}
+	}
+	return prs
+}
+
+// GitHubResolver is the PullRequestResolver of a GitHub repository.
+type GitHubResolver struct {
+	client *github.Client
+	owner  string
+	repo   string
+	logger *slog.Logger
+
+	userNames map[string]string
+}
+
+// NewGitHubResolver returns the resolver of the owner/repo GitHub repository.
+func NewGitHubResolver(client *github.Client, owner, repo string, logger *slog.Logger) *GitHubResolver {
+	if logger == nil {
+		logger = discardLogger()
+	}
+	return &GitHubResolver{
+		client:    client,
+		owner:     owner,
+		repo:      repo,
+		logger:    logger,
+		userNames: make(map[string]string),
+	}
+}
+
+func pullRequestContext(pr *github.PullRequest) *PullRequestContext {
+	labels := make([]string, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		labels = append(labels, label.GetName())
+	}
+
+	return &PullRequestContext{
+		Number: pr.GetNumber(),
+		Title:  pr.GetTitle(),
+		Body:   pr.GetBody(),
+		Author: Contributor{
+			Login:       pr.GetUser().GetLogin(),
+			Association: pr.GetAuthorAssociation(),
+			Bot:         pr.GetUser().GetType() == "Bot",
+		},
+		URL:            pr.GetHTMLURL(),
+		MergedAt:       pr.GetMergedAt().Time,
+		Labels:         labels,
+		BaseBranch:     pr.GetBase().GetRef(),
+		MergeCommitSHA: pr.GetMergeCommitSHA(),
+	}
+}
+
+func findMergedPullRequest(prs []*github.PullRequest) *github.PullRequest {
+	for i := len(prs) - 1; i >= 0; i-- {
+		if prs[i].MergedAt != nil {
+			return prs[i]
+		}
+	}
+
+	return nil
+}
+
+func (r *GitHubResolver) fetchMergedPullRequestFromCommitMessage(commit string) (*github.PullRequest, error) {
+	repoCommit, _, err := r.client.Repositories.GetCommit(context.TODO(), r.owner, r.repo, commit, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit message for %s: %v", commit, err)
+	}
+
+	matches := pullRequestRefPattern.FindAllStringSubmatch(repoCommit.GetCommit().GetMessage(), -1)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[int]struct{}, len(matches))
+	for i := len(matches) - 1; i >= 0; i-- {
+		prNumber, err := strconv.Atoi(matches[i][1])
+		if err != nil {
+			continue
+		}
+
+		if _, ok := seen[prNumber]; ok {
+			continue
+		}
+		seen[prNumber] = struct{}{}
+
+		pr, resp, err := r.client.PullRequests.Get(context.TODO(), r.owner, r.repo, prNumber)
+		if err != nil {
+			if resp == nil || resp.StatusCode != http.StatusNotFound {
+				r.logger.Debug("failed to fetch PR referenced by commit message", "sha", commit, "pr", prNumber, "error", err)
+			}
+			continue
+		}
+		if pr.MergedAt == nil {
+			continue
+		}
+		if pr.GetMergeCommitSHA() == commit {
+			return pr, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// MergedPullRequest finds the merged PR that introduced the given commit via
+// the GitHub "list PRs associated with a commit" API and, failing that, by
+// parsing PR references out of the commit message.
+func (r *GitHubResolver) MergedPullRequest(commit string) (*PullRequestContext, error) {
+	prs, _, err := r.client.PullRequests.ListPullRequestsWithCommit(context.TODO(), r.owner, r.repo, commit, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pulls for commit %s: %v", commit, err)
+	}
+
+	mergedPR := findMergedPullRequest(prs)
+	if mergedPR == nil {
+		mergedPR, err = r.fetchMergedPullRequestFromCommitMessage(commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve merged PR from commit message: %v", err)
+		}
+		if mergedPR == nil {
+			return nil, nil
+		}
+		r.logger.Debug("resolved merged PR from commit message", "sha", commit, "pr", mergedPR.GetNumber())
+	}
+
+	return pullRequestContext(mergedPR), nil
+}
+
+// PullRequest fetches the PR with the given number.
+func (r *GitHubResolver) PullRequest(number int) (*PullRequestContext, error) {
+	pr, resp, err := r.client.PullRequests.Get(context.TODO(), r.owner, r.repo, number)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch PR #%d: %v", number, err)
+	}
+	return pullRequestContext(pr), nil
+}
+
+// UserName returns the display name of the GitHub user, or "" when it is unset
+// or cannot be fetched. Results are cached for the resolver's lifetime.
+func (r *GitHubResolver) UserName(login string) string {
+	if name, ok := r.userNames[login]; ok {
+		return name
+	}
+
+	user, _, err := r.client.Users.Get(context.TODO(), login)
+	if err != nil {
+		// Remember the failure too, so a missing or deleted user is only
+		// looked up once.
+		r.logger.Debug("failed to fetch user", "login", login, "error", err)
+		r.userNames[login] = ""
+		return ""
+	}
+
+	r.userNames[login] = user.GetName()
+	return r.userNames[login]
+}
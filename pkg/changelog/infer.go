@@ -0,0 +1,209 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Kong/changelog/utils"
+)
+
+// InferenceConfig maps PR data to entry types and scopes for Options.Infer.
+type InferenceConfig struct {
+	// Types maps a conventional-commit type (e.g. "fix"), or a type and scope
+	// (e.g. "chore(deps)"), to an entry type. The latter takes precedence.
+	Types map[string]string `yaml:"types"`
+
+	// TitleScopes maps the first segment of a conventional-commit scope
+	// (e.g. "plugins" in "fix(plugins/acl): ...") to an entry scope.
+	TitleScopes map[string]string `yaml:"title_scopes"`
+
+	// Paths maps the files changed by the attributed commit to an entry scope.
+	// The first rule matching any changed file wins.
+	Paths []PathRule `yaml:"paths"`
+}
+
+// PathRule assigns Scope to changes under Pattern, a path.Match pattern
+// matched against each changed file and its parent directories, so
+// "kong/plugins/*" covers everything below kong/plugins.
+type PathRule struct {
+	Pattern string `yaml:"pattern"`
+	Scope   string `yaml:"scope"`
+}
+
+func defaultInferenceConfig() InferenceConfig {
+	return InferenceConfig{
+		Types: map[string]string{
+			"feat":        "feature",
+			"fix":         "bugfix",
+			"perf":        "performance",
+			"deps":        "dependency",
+			"chore(deps)": "dependency",
+			"build(deps)": "dependency",
+		},
+		TitleScopes: map[string]string{
+			"core":       "Core",
+			"plugin":     "Plugin",
+			"plugins":    "Plugin",
+			"pdk":        "PDK",
+			"admin":      "Admin API",
+			"admin-api":  "Admin API",
+			"conf":       "Configuration",
+			"config":     "Configuration",
+			"clustering": "Clustering",
+			"portal":     "Portal",
+			"cli":        "CLI Command",
+		},
+		Paths: []PathRule{
+			{Pattern: "kong/plugins/*", Scope: "Plugin"},
+			{Pattern: "kong/pdk/*", Scope: "PDK"},
+			{Pattern: "kong/api/*", Scope: "Admin API"},
+			{Pattern: "kong/clustering/*", Scope: "Clustering"},
+			{Pattern: "kong/cmd/*", Scope: "CLI Command"},
+			{Pattern: "kong/conf_loader/*", Scope: "Configuration"},
+			{Pattern: "kong.conf.default", Scope: "Configuration"},
+			{Pattern: "kong/*", Scope: "Core"},
+		},
+	}
+}
+
+const breakingChangeType = "breaking_change"
+
+// conventionalTitlePattern matches a conventional-commit PR title such as
+// "fix(plugins/acl): ..." or "feat!: ...", capturing the type, the optional
+// scope and the breaking-change marker.
+var conventionalTitlePattern = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s`)
+
+// inferType returns the entry type implied by a conventional-commit PR title,
+// or "" when the title does not follow the convention or its type is unmapped.
+func (cfg *InferenceConfig) inferType(title string) string {
+	match := conventionalTitlePattern.FindStringSubmatch(title)
+	if match == nil {
+		return ""
+	}
+
+	if match[3] != "" {
+		return breakingChangeType
+	}
+
+	kind := strings.ToLower(match[1])
+	if match[2] != "" {
+		if t, ok := cfg.Types[kind+"("+strings.ToLower(match[2])+")"]; ok {
+			return t
+		}
+	}
+	return cfg.Types[kind]
+}
+
+// inferTitleScope returns the entry scope implied by the scope of a
+// conventional-commit PR title, or "".
+func (cfg *InferenceConfig) inferTitleScope(title string) string {
+	match := conventionalTitlePattern.FindStringSubmatch(title)
+	if match == nil || match[2] == "" {
+		return ""
+	}
+
+	segment, _, _ := strings.Cut(strings.ToLower(match[2]), "/")
+	return cfg.TitleScopes[strings.TrimSpace(segment)]
+}
+
+// matchPath reports whether pattern matches file or one of its parent
+// directories.
+func matchPath(pattern, file string) bool {
+	for p := file; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inferPathScope returns the scope of the first path rule matching any of
+// files, or "".
+func (cfg *InferenceConfig) inferPathScope(files []string) string {
+	for _, rule := range cfg.Paths {
+		for _, file := range files {
+			if matchPath(rule.Pattern, file) {
+				return rule.Scope
+			}
+		}
+	}
+	return ""
+}
+
+// inferEntry fills in the type and scope the entry omits from its attributed
+// commit context, recording each inferred field in entry.Inferred.
+func (g *Generator) inferEntry(entry *ChangelogEntry, ctx CommitContext) {
+	cfg := &g.config.Inference
+
+	if entry.Type == "" {
+		if t := cfg.inferType(ctx.PrCtx.Title); t != "" {
+			entry.Type = t
+			entry.Inferred = append(entry.Inferred, "type")
+			g.logger.Debug("inferred entry type from PR title", "file", entry.fileName, "pr", ctx.PrCtx.Number, "type", t, "title", ctx.PrCtx.Title)
+		}
+	}
+
+	if entry.Scope == "" {
+		scope := cfg.inferTitleScope(ctx.PrCtx.Title)
+		if scope == "" {
+			files, err := utils.ChangedFiles(g.options.RepoPath, ctx.SHA)
+			if err != nil {
+				g.logger.Debug("failed to list changed files", "file", entry.fileName, "sha", ctx.SHA, "error", err)
+			}
+			scope = cfg.inferPathScope(files)
+		}
+		if scope != "" {
+			entry.Scope = scope
+			entry.Inferred = append(entry.Inferred, "scope")
+			g.logger.Debug("inferred entry scope", "file", entry.fileName, "sha", ctx.SHA, "scope", scope)
+		}
+	}
+}
+
+// inferredEntries returns the entries of maps with inferred fields, sorted by
+// file name.
+func inferredEntries(maps map[string]map[string][]*ChangelogEntry) []*ChangelogEntry {
+	inferred := make([]*ChangelogEntry, 0)
+	for _, scopeEntries := range maps {
+		for _, entries := range scopeEntries {
+			for _, entry := range entries {
+				if len(entry.Inferred) > 0 {
+					inferred = append(inferred, entry)
+				}
+			}
+		}
+	}
+	sort.Slice(inferred, func(i, j int) bool {
+		return inferred[i].fileName < inferred[j].fileName
+	})
+	return inferred
+}
+
+func (g *Generator) writeInferenceSummary(w io.Writer) {
+	inferred := g.inferredEntries
+	if len(inferred) == 0 {
+		return
+	}
+
+	entryNoun := "entries"
+	if len(inferred) == 1 {
+		entryNoun = "entry"
+	}
+
+	fmt.Fprintf(w, "\ninferred fields of %d changelog %s:\n", len(inferred), entryNoun)
+	for i, entry := range inferred {
+		fields := make([]string, 0, len(entry.Inferred))
+		for _, field := range entry.Inferred {
+			value := entry.Type
+			if field == "scope" {
+				value = entry.Scope
+			}
+			fields = append(fields, field+": "+value)
+		}
+		fmt.Fprintf(w, "%d. file: %s\n   %s\n", i+1, entry.fileName, strings.Join(fields, "\n   "))
+	}
+}
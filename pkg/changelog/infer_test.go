@@ -0,0 +1,132 @@
+package changelog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferType(t *testing.T) {
+	cfg := defaultInferenceConfig()
+
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "fix(plugins/acl): hide groups header", want: "bugfix"},
+		{title: "feat: add an option", want: "feature"},
+		{title: "Feat(core): upper-case type", want: "feature"},
+		{title: "feat!: drop the old option", want: breakingChangeType},
+		{title: "fix(core)!: change the default", want: breakingChangeType},
+		{title: "chore(deps): bump OpenSSL to 3.2", want: "dependency"},
+		{title: "chore(ci): fix the workflow", want: ""},
+		{title: "docs: fix a typo", want: ""},
+		{title: "Fixed the balancer", want: ""},
+		{title: "fix:missing space", want: ""},
+	}
+
+	for _, tc := range tests {
+		if got := cfg.inferType(tc.title); got != tc.want {
+			t.Errorf("inferType(%q) = %q, want %q", tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestInferTitleScope(t *testing.T) {
+	cfg := defaultInferenceConfig()
+
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "fix(plugins/acl): hide groups header", want: "Plugin"},
+		{title: "feat(Admin-API): add an endpoint", want: "Admin API"},
+		{title: "fix( pdk ): trim the scope", want: "PDK"},
+		{title: "fix(unknown): no such scope", want: ""},
+		{title: "fix: no scope", want: ""},
+		{title: "Fixed the balancer", want: ""},
+	}
+
+	for _, tc := range tests {
+		if got := cfg.inferTitleScope(tc.title); got != tc.want {
+			t.Errorf("inferTitleScope(%q) = %q, want %q", tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{pattern: "kong/plugins/*", file: "kong/plugins/acl/handler.lua", want: true},
+		{pattern: "kong/plugins/*", file: "kong/plugins", want: false},
+		{pattern: "kong.conf.default", file: "kong.conf.default", want: true},
+		{pattern: "kong/*", file: "spec/kong/init.lua", want: false},
+		{pattern: "kong/*", file: "kong/init.lua", want: true},
+	}
+
+	for _, tc := range tests {
+		if got := matchPath(tc.pattern, tc.file); got != tc.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tc.pattern, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestInferPathScope(t *testing.T) {
+	cfg := defaultInferenceConfig()
+
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		// The first matching rule wins, not the first file.
+		{files: []string{"kong/init.lua", "kong/plugins/acl/handler.lua"}, want: "Plugin"},
+		{files: []string{"kong/runloop/handler.lua"}, want: "Core"},
+		{files: []string{"kong.conf.default"}, want: "Configuration"},
+		{files: []string{"spec/01-unit/foo_spec.lua"}, want: ""},
+		{files: nil, want: ""},
+	}
+
+	for _, tc := range tests {
+		if got := cfg.inferPathScope(tc.files); got != tc.want {
+			t.Errorf("inferPathScope(%v) = %q, want %q", tc.files, got, tc.want)
+		}
+	}
+}
+
+func TestInferEntryWithoutType(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	inferred := commitFile(t, dir, "changelog/unreleased/kong/inferred.yml", "message: Dropped the old option.\n", "feat!: drop the old option (#10)")
+	untyped := commitFile(t, dir, "changelog/unreleased/kong/untyped.yml", "message: Updated the docs.\n", "Update the docs (#11)")
+
+	resolver := fakeResolver{
+		inferred: {Number: 10, Title: "feat!: drop the old option", MergedAt: time.Now(), MergeCommitSHA: inferred},
+		untyped:  {Number: 11, Title: "Update the docs", MergedAt: time.Now(), MergeCommitSHA: untyped},
+	}
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+		Infer:           true,
+	}, DefaultConfig(), resolver, nil)
+
+	data, failures, err := generator.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || filepath.Base(failures[0].FileName) != "untyped.yml" || failures[0].CommitSHA != untyped ||
+		!strings.Contains(failures[0].Err.Error(), `could not be inferred from the PR title "Update the docs"`) {
+		t.Fatalf("failures = %+v, want untyped.yml only", failures)
+	}
+	if _, ok := data.Type[""]; ok {
+		t.Fatal("an entry was collected without a type")
+	}
+	if scopes := data.Type[breakingChangeType]; len(scopes) != 1 || len(scopes[0].Entries) != 1 {
+		t.Fatalf("breaking changes = %+v, want inferred.yml", scopes)
+	}
+}
@@ -0,0 +1,399 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LintConfig tunes the message style rules of Linter.
+type LintConfig struct {
+	// Rules turns the named rules on (true) or off (false). A rule that is
+	// not listed is on.
+	Rules map[string]bool `yaml:"rules"`
+
+	// MaxLineLength is the longest a message line may be, in characters, for
+	// the max-line-length rule.
+	MaxLineLength int `yaml:"max_line_length"`
+}
+
+func defaultLintConfig() LintConfig {
+	return LintConfig{
+		MaxLineLength: 200,
+	}
+}
+
+// enabled reports whether the rule named name is on.
+func (c *LintConfig) enabled(name string) bool {
+	on, ok := c.Rules[name]
+	return !ok || on
+}
+
+// LintRule is a style rule for the entry messages.
+type LintRule interface {
+	// Name is the name turning the rule off in LintConfig.Rules, or in an
+	// entry file with a "# lint-disable <name>" comment.
+	Name() string
+
+	// Check returns the problems of the entry's message. The entry has its
+	// Message, Type, Scope and Plugins set, with the message given without
+	// the badge and the bold plugin names it may start with.
+	Check(entry *ChangelogEntry) []LintProblem
+}
+
+// LintProblem is a message breaking a LintRule.
+type LintProblem struct {
+	// Line is the line of the message the problem is on, from 0.
+	Line    int
+	Message string
+	// Suggestion is the likely intended text, or "" when there is none.
+	Suggestion string
+}
+
+// messageRule is a LintRule checking the message text alone.
+type messageRule struct {
+	name  string
+	check func(message string) []LintProblem
+}
+
+func (r *messageRule) Name() string {
+	return r.name
+}
+
+func (r *messageRule) Check(entry *ChangelogEntry) []LintProblem {
+	return r.check(entry.Message)
+}
+
+// DefaultLintRules returns the style rules of the Kong docs team:
+// past-tense, sentence-case, terminal-period, no-raw-urls,
+// config-key-backticks and max-line-length.
+func DefaultLintRules(config LintConfig) []LintRule {
+	return []LintRule{
+		&messageRule{name: "past-tense", check: checkPastTense},
+		&messageRule{name: "sentence-case", check: checkSentenceCase},
+		&messageRule{name: "terminal-period", check: checkTerminalPeriod},
+		&messageRule{name: "no-raw-urls", check: checkRawURLs},
+		&messageRule{name: "config-key-backticks", check: checkConfigKeys},
+		&messageRule{name: "max-line-length", check: func(message string) []LintProblem {
+			return checkLineLength(message, config.MaxLineLength)
+		}},
+	}
+}
+
+// pastTenses maps the verbs changelog messages commonly start with to their
+// past tense.
+var pastTenses = map[string]string{
+	"add":       "added",
+	"allow":     "allowed",
+	"build":     "built",
+	"bump":      "bumped",
+	"change":    "changed",
+	"cut":       "cut",
+	"deprecate": "deprecated",
+	"disable":   "disabled",
+	"drop":      "dropped",
+	"enable":    "enabled",
+	"ensure":    "ensured",
+	"fix":       "fixed",
+	"improve":   "improved",
+	"introduce": "introduced",
+	"keep":      "kept",
+	"make":      "made",
+	"move":      "moved",
+	"optimize":  "optimized",
+	"prevent":   "prevented",
+	"put":       "put",
+	"refactor":  "refactored",
+	"remove":    "removed",
+	"rename":    "renamed",
+	"replace":   "replaced",
+	"reset":     "reset",
+	"rewrite":   "rewrote",
+	"run":       "ran",
+	"set":       "set",
+	"split":     "split",
+	"stop":      "stopped",
+	"support":   "supported",
+	"update":    "updated",
+	"upgrade":   "upgraded",
+	"use":       "used",
+	"write":     "wrote",
+}
+
+// pastTense returns the past tense of word, a verb of pastTenses in the
+// present tense or the third person, or "" for any other word.
+func pastTense(word string) string {
+	lower := strings.ToLower(word)
+	for _, base := range []string{lower, strings.TrimSuffix(lower, "s"), strings.TrimSuffix(lower, "es")} {
+		if past, ok := pastTenses[base]; ok {
+			return past
+		}
+	}
+	return ""
+}
+
+// isPastTense reports whether word is a verb in the past tense.
+func isPastTense(word string) bool {
+	lower := strings.ToLower(word)
+	if strings.HasSuffix(lower, "ed") {
+		return true
+	}
+	for _, past := range pastTenses {
+		if lower == past {
+			return true
+		}
+	}
+	return false
+}
+
+// firstWord returns the leading run of letters of message.
+func firstWord(message string) string {
+	end := strings.IndexFunc(message, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		return message
+	}
+	return message[:end]
+}
+
+// matchCase returns word with the case of its first letter taken from like.
+func matchCase(word, like string) string {
+	first, _ := utf8.DecodeRuneInString(like)
+	if unicode.IsUpper(first) {
+		return capitalize(word)
+	}
+	return word
+}
+
+func capitalize(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(first)) + word[size:]
+}
+
+func checkPastTense(message string) []LintProblem {
+	word := firstWord(message)
+	if word == "" || isPastTense(word) {
+		return nil
+	}
+	suggestion := pastTense(word)
+	if suggestion != "" {
+		suggestion = matchCase(suggestion, word)
+	}
+	return []LintProblem{{
+		Message:    fmt.Sprintf("message should start with a verb in the past tense, e.g. \"Fixed\" or \"Added\", not %q", word),
+		Suggestion: suggestion,
+	}}
+}
+
+func checkSentenceCase(message string) []LintProblem {
+	first, _ := utf8.DecodeRuneInString(message)
+	if !unicode.IsLower(first) {
+		return nil
+	}
+	word := firstWord(message)
+	return []LintProblem{{
+		Message:    fmt.Sprintf("message should start with a capital letter, not %q", word),
+		Suggestion: capitalize(word),
+	}}
+}
+
+func checkTerminalPeriod(message string) []LintProblem {
+	trimmed := strings.TrimRightFunc(message, unicode.IsSpace)
+	if trimmed == "" || strings.HasSuffix(trimmed, ".") {
+		return nil
+	}
+	return []LintProblem{{
+		Line:    strings.Count(trimmed, "\n"),
+		Message: "message should end with a period",
+	}}
+}
+
+// urlPattern matches the URLs of a message, without the punctuation ending
+// the sentence.
+var urlPattern = regexp.MustCompile(`https?://[^\s)>\]]*[^\s)>\].,;:!?]`)
+
+func checkRawURLs(message string) []LintProblem {
+	problems := make([]LintProblem, 0)
+	for _, match := range urlPattern.FindAllStringIndex(message, -1) {
+		before := message[:match[0]]
+		if strings.HasSuffix(before, "](") || strings.HasSuffix(before, "<") {
+			continue
+		}
+		url := message[match[0]:match[1]]
+		problems = append(problems, LintProblem{
+			Line:       strings.Count(before, "\n"),
+			Message:    fmt.Sprintf("raw URL %s should be a Markdown link", url),
+			Suggestion: fmt.Sprintf("[...](%s)", url),
+		})
+	}
+	return problems
+}
+
+// codeSpanPattern matches the code spans of a message.
+var codeSpanPattern = regexp.MustCompile("`[^`]*`")
+
+// configKeyPattern matches the snake_case words of a message, such as the
+// nginx_http_lua_shared_dict config key or the config.redis_host plugin
+// field.
+var configKeyPattern = regexp.MustCompile(`\b[a-z][a-z0-9]*(?:\.[a-z0-9]+)*(?:_[a-z0-9]+)+(?:\.[a-z0-9_]+)*\b`)
+
+func checkConfigKeys(message string) []LintProblem {
+	// Blank out the code spans and URLs rather than removing them, so the
+	// offsets still tell the line.
+	blank := func(s string) string { return strings.Repeat(" ", len(s)) }
+	text := codeSpanPattern.ReplaceAllStringFunc(message, blank)
+	text = urlPattern.ReplaceAllStringFunc(text, blank)
+
+	problems := make([]LintProblem, 0)
+	for _, match := range configKeyPattern.FindAllStringIndex(text, -1) {
+		key := text[match[0]:match[1]]
+		problems = append(problems, LintProblem{
+			Line:       strings.Count(text[:match[0]], "\n"),
+			Message:    fmt.Sprintf("config key %s should be in backticks", key),
+			Suggestion: "`" + key + "`",
+		})
+	}
+	return problems
+}
+
+func checkLineLength(message string, maxLength int) []LintProblem {
+	if maxLength <= 0 {
+		return nil
+	}
+	problems := make([]LintProblem, 0)
+	for i, line := range strings.Split(message, "\n") {
+		if length := utf8.RuneCountInString(line); length > maxLength {
+			problems = append(problems, LintProblem{
+				Line:    i,
+				Message: fmt.Sprintf("message line should be at most %d characters, got %d", maxLength, length),
+			})
+		}
+	}
+	return problems
+}
+
+// Linter checks the messages of entry files against style rules.
+type Linter struct {
+	config Config
+	rules  []LintRule
+}
+
+// NewLinter returns a Linter applying rules, or DefaultLintRules when there
+// are none, except the ones config.Lint turns off.
+func NewLinter(config Config, rules ...LintRule) *Linter {
+	if len(rules) == 0 {
+		rules = DefaultLintRules(config.Lint)
+	}
+	return &Linter{
+		config: config,
+		rules:  rules,
+	}
+}
+
+// lintDisablePattern matches the comments suppressing rules in an entry file:
+// "# lint-disable" for all of them, or "# lint-disable past-tense,
+// terminal-period" for some.
+var lintDisablePattern = regexp.MustCompile(`(?m)^#\s*lint-disable\b:?(.*)$`)
+
+// disabledRules adds to disabled the rules the comments of node and its
+// children suppress, with "*" standing for all of them.
+func disabledRules(node *yaml.Node, disabled map[string]bool) {
+	for _, comment := range []string{node.HeadComment, node.LineComment, node.FootComment} {
+		for _, match := range lintDisablePattern.FindAllStringSubmatch(comment, -1) {
+			names := strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+			if len(names) == 0 {
+				disabled["*"] = true
+			}
+			for _, name := range names {
+				disabled[name] = true
+			}
+		}
+	}
+	for _, child := range node.Content {
+		disabledRules(child, disabled)
+	}
+}
+
+// LintFile checks the message of the entry file content read from file
+// against the rules of the linter. An entry without a message string is not
+// checked, as ValidateFile reports it, but an entry that is not YAML yields
+// its ValidateFile diagnostic.
+func (l *Linter) LintFile(file string, content []byte) []Diagnostic {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return l.config.ValidateFile(file, content)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	// Only the message, type and scope are read, so that a mistake in the
+	// other fields, left to ValidateFile, does not keep the message from
+	// being checked.
+	entry := ChangelogEntry{fileName: file}
+	var message *yaml.Node
+	mapping := doc.Content[0]
+	for i := len(mapping.Content) - 2; i >= 0; i -= 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if !isString(value) {
+			continue
+		}
+		switch key.Value {
+		case "message":
+			message, entry.Message = value, value.Value
+		case "type":
+			entry.Type = value.Value
+		case "scope":
+			entry.Scope = value.Value
+		}
+	}
+	if message == nil {
+		return nil
+	}
+	if match := pluginPrefixPattern.FindStringIndex(entry.Message); match != nil {
+		entry.Plugins, _ = parsePlugins(entry.Message)
+		entry.Message = entry.Message[match[1]:]
+	}
+
+	disabled := make(map[string]bool)
+	disabledRules(&doc, disabled)
+
+	lines := strings.Split(string(content), "\n")
+	block := message.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+	diagnostics := make([]Diagnostic, 0)
+	for _, rule := range l.rules {
+		name := rule.Name()
+		if !l.config.Lint.enabled(name) || disabled["*"] || disabled[name] {
+			continue
+		}
+		for _, problem := range rule.Check(&entry) {
+			d := Diagnostic{
+				File:       file,
+				Line:       message.Line,
+				Column:     message.Column,
+				Field:      "message",
+				Rule:       name,
+				Message:    problem.Message,
+				Suggestion: problem.Suggestion,
+			}
+			// The lines of a block scalar start on the line after its
+			// indicator, and are the lines of the file.
+			if block {
+				d.Line = message.Line + 1 + problem.Line
+				if d.Line <= len(lines) {
+					line := lines[d.Line-1]
+					d.Column = len(line) - len(strings.TrimLeft(line, " ")) + 1
+				}
+			}
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+	return diagnostics
+}
@@ -0,0 +1,105 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		lint    LintConfig
+		content string
+		want    []string
+	}{
+		{
+			name:    "valid",
+			content: "message: \"**acl**: Fixed the `config.hide_groups_header` field, see [the docs](https://docs.konghq.com).\"\ntype: bugfix\nscope: Plugin\n",
+			want:    nil,
+		},
+		{
+			name:    "present tense",
+			content: "message: Fixes an issue.\ntype: bugfix\n",
+			want:    []string{`entry.yml:1:10: message should start with a verb in the past tense, e.g. "Fixed" or "Added", not "Fixes" (did you mean "Fixed"?) [past-tense]`},
+		},
+		{
+			name:    "lower case and no period",
+			content: "message: added a thing\ntype: feature\n",
+			want: []string{
+				`entry.yml:1:10: message should start with a capital letter, not "added" (did you mean "Added"?) [sentence-case]`,
+				"entry.yml:1:10: message should end with a period [terminal-period]",
+			},
+		},
+		{
+			name:    "raw URL and config key",
+			content: "message: |\n  Added the lua_ssl_protocols option.\n  See https://example.com.\ntype: feature\n",
+			want: []string{
+				"entry.yml:2:3: config key lua_ssl_protocols should be in backticks (did you mean \"`lua_ssl_protocols`\"?) [config-key-backticks]",
+				`entry.yml:3:3: raw URL https://example.com should be a Markdown link (did you mean "[...](https://example.com)"?) [no-raw-urls]`,
+			},
+		},
+		{
+			name:    "line length",
+			lint:    LintConfig{MaxLineLength: 10},
+			content: "message: Added a thing.\ntype: feature\n",
+			want:    []string{"entry.yml:1:10: message line should be at most 10 characters, got 14 [max-line-length]"},
+		},
+		{
+			name:    "rule turned off",
+			lint:    LintConfig{Rules: map[string]bool{"terminal-period": false}},
+			content: "message: Added a thing\ntype: feature\n",
+			want:    nil,
+		},
+		{
+			name:    "rule suppressed",
+			content: "message: Added a thing # lint-disable terminal-period\ntype: feature\n",
+			want:    nil,
+		},
+		{
+			name:    "all rules suppressed",
+			content: "# lint-disable\nmessage: add a thing\ntype: feature\n",
+			want:    nil,
+		},
+		{
+			name:    "invalid entry",
+			content: "message: [Added a thing]\ntype: feature\n",
+			want:    nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Lint = tc.lint
+			got := make([]string, 0)
+			for _, d := range NewLinter(config).LintFile("entry.yml", []byte(tc.content)) {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("LintFile() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
+
+// scopedFeature is a rule of another repo, requiring features to be scoped.
+type scopedFeature struct{}
+
+func (scopedFeature) Name() string {
+	return "scoped-feature"
+}
+
+func (scopedFeature) Check(entry *ChangelogEntry) []LintProblem {
+	if entry.Type != "feature" || entry.Scope != "" {
+		return nil
+	}
+	return []LintProblem{{Message: "feature entries should have a scope"}}
+}
+
+func TestLintFileCustomRule(t *testing.T) {
+	linter := NewLinter(DefaultConfig(), scopedFeature{})
+	got := linter.LintFile("entry.yml", []byte("message: added a thing\ntype: feature\n"))
+	if len(got) != 1 || got[0].String() != "entry.yml:1:10: feature entries should have a scope [scoped-feature]" {
+		t.Fatalf("LintFile() = %v, want the scoped-feature rule only", got)
+	}
+}
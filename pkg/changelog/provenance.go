@@ -0,0 +1,219 @@
+package changelog
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Kong/changelog/utils"
+)
+
+const (
+	presenceAncestor      = "ancestor"
+	presenceTrailer       = "cherry-pick source"
+	presenceCherryPick    = "cherry-pick"
+	presencePatchID       = "patch-id"
+	presenceChangelogFile = "changelog file"
+
+	// PatchIDChangelog matches commits by the patch ID of their change to the
+	// entry's changelog file, PatchIDFull by that of their whole diff.
+	PatchIDChangelog = "changelog"
+	PatchIDFull      = "full"
+)
+
+// Presence describes how the change behind an entry was found on a branch:
+// the commit on the branch that carries it and the provenance signal that
+// matched it.
+type Presence struct {
+	Commit string `json:"commit"`
+	Via    string `json:"via"`
+}
+
+// ProvenanceFinder locates the change behind an entry on other branches.
+type ProvenanceFinder struct {
+	repoPath string
+	logger   *slog.Logger
+	// head is the ref the entries are read at. Only the commits of a branch
+	// that are not reachable from head are considered for patch-id matching.
+	head string
+	// patchID is the patch-id matching mode: "" (disabled), PatchIDChangelog
+	// or PatchIDFull.
+	patchID string
+	// fullPatchIDs caches, per branch, the whole-diff patch IDs of its commits;
+	// history caches those of the changes to the changelog files.
+	fullPatchIDs map[string]map[string]string
+	// history answers the commit, trailer and file origin lookups of the run.
+	history *utils.History
+}
+
+// NewProvenanceFinder returns the finder of the entries under changelogPaths
+// read at head (HEAD when empty), matching by patch ID in the patchID mode
+// ("" to disable it).
+func NewProvenanceFinder(repoPath, head, patchID string, changelogPaths []string, logger *slog.Logger) *ProvenanceFinder {
+	if head == "" {
+		head = "HEAD"
+	}
+	if logger == nil {
+		logger = discardLogger()
+	}
+	return &ProvenanceFinder{
+		repoPath:     repoPath,
+		logger:       logger,
+		head:         head,
+		patchID:      patchID,
+		fullPatchIDs: make(map[string]map[string]string),
+		history:      utils.NewHistory(repoPath, changelogPaths),
+	}
+}
+
+// Close stops the git processes kept open to read the repository. The finder
+// can still be used afterwards; they are restarted on demand.
+func (f *ProvenanceFinder) Close() error {
+	return utils.CloseRepository(f.repoPath)
+}
+
+// ValidatePatchIDMode checks a patch-id matching mode.
+func ValidatePatchIDMode(mode string) error {
+	if mode != "" && mode != PatchIDChangelog && mode != PatchIDFull {
+		return fmt.Errorf("unknown patch-id %q, must be one of: %s, %s", mode, PatchIDChangelog, PatchIDFull)
+	}
+	return nil
+}
+
+// findByPatchID returns the commit on branch whose patch ID equals that of
+// commit, or "" when there is none or patch-id matching is disabled.
+func (f *ProvenanceFinder) findByPatchID(branch, commit, file string) string {
+	var paths []string
+	if f.patchID == PatchIDChangelog {
+		paths = []string{file}
+	} else if f.patchID != PatchIDFull {
+		return ""
+	}
+
+	id, err := utils.PatchID(f.repoPath, commit, paths...)
+	if err != nil || id == "" {
+		return ""
+	}
+
+	if paths != nil {
+		onBranch, err := f.history.FindFilePatchIDOnBranch(branch, f.head, file, id)
+		if err != nil {
+			f.logger.Debug("patch-id lookup failed", "branch", branch, "error", err)
+		}
+		return onBranch
+	}
+
+	ids := f.fullPatchIDs[branch]
+	if ids == nil {
+		ids, err = utils.PatchIDsOnBranch(f.repoPath, branch, f.head)
+		if err != nil {
+			f.logger.Debug("patch-id lookup failed", "branch", branch, "error", err)
+			return ""
+		}
+		f.fullPatchIDs[branch] = ids
+	}
+	return ids[id]
+}
+
+// find looks for the change introduced by commit, the commit that added the
+// changelog file, on branch. src returns the commit's cherry-pick source and
+// is only called when needed.
+//
+// A fix-release branch (e.g. next/3.14.0.9) is cut from the minor branch and
+// then synced by cherry-picking. The introducing commit is either:
+//
+//   - already on the branch (present before the cut) — use it as-is; or
+//   - synced in after the cut — locate its counterpart on the branch by,
+//     in order: (1) the `git cherry-pick -x` trailer, when the recorded source
+//     is itself on the branch; (2) the branch commit that cherry-picked the
+//     same upstream source (the backport commit), or the commit itself; (3)
+//     when enabled, the branch commit with the same patch ID, which catches
+//     backports re-applied with `git am` or by hand that carry no trailer; (4)
+//     the branch commit that introduced the entry's changelog file, which is
+//     cherry-picked verbatim during the sync and so identifies the counterpart
+//     even when no trailer was recorded (e.g. a backport applied without -x)
+//     and regardless of what else the sync commit touched.
+//
+// It returns false when none matches.
+func (f *ProvenanceFinder) find(branch, commit, file string, src func() string) (Presence, bool) {
+	if utils.IsAncestor(f.repoPath, commit, branch) {
+		return Presence{Commit: commit, Via: presenceAncestor}, true
+	}
+
+	// Prefer the recorded cherry-pick provenance (exact and cheap): the source
+	// itself when it is on the branch, else the branch commit that backported
+	// the same source.
+	if src() != "" {
+		if utils.IsAncestor(f.repoPath, src(), branch) {
+			return Presence{Commit: src(), Via: presenceTrailer}, true
+		}
+		if onBranch := f.history.FindCherryPickOnBranch(branch, src()); onBranch != "" {
+			return Presence{Commit: onBranch, Via: presenceCherryPick}, true
+		}
+	}
+
+	if onBranch := f.history.FindCherryPickOnBranch(branch, commit); onBranch != "" {
+		return Presence{Commit: onBranch, Via: presenceCherryPick}, true
+	}
+
+	if onBranch := f.findByPatchID(branch, commit, file); onBranch != "" {
+		return Presence{Commit: onBranch, Via: presencePatchID}, true
+	}
+
+	// No usable trailer mapping (no trailer, or the source is upstream with no
+	// backport on the branch referencing it): find the branch commit that
+	// introduced this entry's changelog file.
+	if origin := f.history.FindFileOriginOnBranch(branch, file); origin != "" {
+		return Presence{Commit: origin, Via: presenceChangelogFile}, true
+	}
+
+	return Presence{}, false
+}
+
+// cherryPickSourceOf returns a function computing the cherry-pick source of
+// commit on first use.
+func (f *ProvenanceFinder) cherryPickSourceOf(commit string) func() string {
+	var src *string
+	return func() string {
+		if src == nil {
+			sha := f.history.FindCherryPickSource(commit)
+			src = &sha
+		}
+		return *src
+	}
+}
+
+// findOnBranch looks for the change introduced by commit on branch (see
+// ProvenanceFinder.find).
+func (f *ProvenanceFinder) findOnBranch(branch, commit, file string) (Presence, bool) {
+	return f.find(branch, commit, file, f.cherryPickSourceOf(commit))
+}
+
+// BranchEntry is a changelog entry file on a branch with the commit that
+// introduced it there.
+type BranchEntry struct {
+	File   string
+	Commit string
+}
+
+// listBranchEntries returns the entry files under changelogPaths on branch,
+// with the commit that introduced each.
+func (f *ProvenanceFinder) listBranchEntries(branch string, changelogPaths []string) ([]BranchEntry, error) {
+	files, err := utils.ListFilesAt(f.repoPath, branch, changelogPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]BranchEntry, 0, len(files))
+	for _, file := range files {
+		if !isYAML(file) {
+			continue
+		}
+
+		commit, err := f.history.FindOriginalCommit(branch, file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, BranchEntry{File: file, Commit: commit})
+	}
+	return entries, nil
+}
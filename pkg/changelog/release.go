@@ -0,0 +1,161 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Kong/changelog/utils"
+)
+
+const unreleasedFolder = "unreleased"
+
+// versionPath returns the folder the entries of changelogPath are released
+// into, replacing its "unreleased" segment with version, e.g.
+// changelog/unreleased/kong becomes changelog/3.14.0.9/kong.
+func versionPath(changelogPath, version string) (string, error) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(changelogPath)), "/")
+	for i, part := range parts {
+		if part == unreleasedFolder {
+			parts[i] = version
+			return filepath.FromSlash(strings.Join(parts, "/")), nil
+		}
+	}
+	return "", fmt.Errorf("changelog path %s has no %q folder to release from", changelogPath, unreleasedFolder)
+}
+
+// ReleaseMove is an entry file to move into a version folder, with paths
+// relative to the repository path.
+type ReleaseMove struct {
+	From string
+	To   string
+}
+
+// ReleaseMoves lists the entry files to move from each changelog path to its
+// version folder, with paths relative to the repository path. It fails when a
+// file already exists at its destination, or two files would be moved to the
+// same one.
+func ReleaseMoves(repoPath string, changelogPaths []string, version string) ([]ReleaseMove, error) {
+	moves := make([]ReleaseMove, 0)
+	destinations := make(map[string]string)
+	for _, path := range changelogPaths {
+		target, err := versionPath(path, version)
+		if err != nil {
+			return nil, err
+		}
+
+		exists, err := utils.DirExists(filepath.Join(repoPath, path))
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		files, err := os.ReadDir(filepath.Join(repoPath, path))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() || !isYAML(file.Name()) {
+				continue
+			}
+
+			move := ReleaseMove{
+				From: filepath.Join(path, file.Name()),
+				To:   filepath.Join(target, file.Name()),
+			}
+			if _, err := os.Stat(filepath.Join(repoPath, move.To)); err == nil {
+				return nil, fmt.Errorf("cannot release %s: %s already exists", move.From, move.To)
+			}
+			if from, ok := destinations[move.To]; ok {
+				return nil, fmt.Errorf("cannot release %s: %s is also released to %s", move.From, from, move.To)
+			}
+			destinations[move.To] = move.From
+			moves = append(moves, move)
+		}
+	}
+	return moves, nil
+}
+
+// SplitReleaseMoves splits moves into those of the files in collected (see
+// Generator.Collected), which are released, and the others, which are left in
+// the unreleased folder.
+func SplitReleaseMoves(moves []ReleaseMove, collected []string) ([]ReleaseMove, []ReleaseMove) {
+	released, left := make([]ReleaseMove, 0), make([]ReleaseMove, 0)
+	for _, move := range moves {
+		if contains(collected, filepath.Clean(move.From)) {
+			released = append(released, move)
+		} else {
+			left = append(left, move)
+		}
+	}
+	return released, left
+}
+
+// ApplyReleaseMoves moves the entry files with `git mv`, creating the version
+// folders. When a move fails, the files already moved are moved back, so the
+// tree is left as it was.
+func ApplyReleaseMoves(repoPath string, moves []ReleaseMove) error {
+	for i, move := range moves {
+		err := os.MkdirAll(filepath.Join(repoPath, filepath.Dir(move.To)), 0o755)
+		if err == nil {
+			err = utils.MoveFile(repoPath, move.From, move.To)
+		}
+		if err != nil {
+			if rollbackErr := RevertReleaseMoves(repoPath, moves[:i]); rollbackErr != nil {
+				return fmt.Errorf("%v; rolling back the moves also failed: %v", err, rollbackErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertReleaseMoves moves the entry files moved by ApplyReleaseMoves back,
+// in reverse order, and removes the version folders left empty.
+func RevertReleaseMoves(repoPath string, moves []ReleaseMove) error {
+	for i := len(moves) - 1; i >= 0; i-- {
+		move := moves[i]
+		if err := utils.MoveFile(repoPath, move.To, move.From); err != nil {
+			return err
+		}
+		// os.Remove only removes the folders the move left empty.
+		for dir := filepath.Dir(move.To); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+			if os.Remove(filepath.Join(repoPath, dir)) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// insertRelease adds notes to the changelog document content, before the
+// first existing release ("## ...") heading, or at the end when there is none.
+func insertRelease(content, notes string) string {
+	notes = strings.TrimSpace(notes) + "\n\n"
+
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			return strings.Join(lines[:i], "") + notes + strings.Join(lines[i:], "")
+		}
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n\n") {
+		content = strings.TrimRight(content, "\n") + "\n\n"
+	}
+	return content + notes
+}
+
+// WriteRelease adds notes to the changelog document at path (see
+// insertRelease), creating it when missing.
+func WriteRelease(path, notes string) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(insertRelease(string(content), notes)), 0o644)
+}
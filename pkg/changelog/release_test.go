@@ -0,0 +1,191 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "changelog/unreleased/kong", want: "changelog/3.14.0.9/kong"},
+		{path: "changelog/unreleased/kong/", want: "changelog/3.14.0.9/kong"},
+		{path: "unreleased", want: "3.14.0.9"},
+		{path: "changelog/kong", wantErr: true},
+		{path: "changelog/unreleased-kong", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := versionPath(filepath.FromSlash(tc.path), "3.14.0.9")
+		if (err != nil) != tc.wantErr || got != filepath.FromSlash(tc.want) {
+			t.Errorf("versionPath(%q) = %q, %v, want %q (error: %v)", tc.path, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestInsertRelease(t *testing.T) {
+	notes := "## 3.14.0.9\n\n### Fixes\n\n- Fixed a crash.\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "empty document",
+			content: "",
+			want:    notes + "\n",
+		},
+		{
+			name:    "no release heading",
+			content: "# Changelog\n\nAll notable changes.\n",
+			want:    "# Changelog\n\nAll notable changes.\n\n" + notes + "\n",
+		},
+		{
+			name:    "before the first release heading",
+			content: "# Changelog\n\n## 3.14.0.8\n\n- Older.\n\n## 3.14.0.7\n",
+			want:    "# Changelog\n\n" + notes + "\n## 3.14.0.8\n\n- Older.\n\n## 3.14.0.7\n",
+		},
+		{
+			name:    "heading on the first line",
+			content: "## 3.14.0.8\n",
+			want:    notes + "\n## 3.14.0.8\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := insertRelease(tc.content, notes); got != tc.want {
+				t.Fatalf("insertRelease() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReleaseMoves(t *testing.T) {
+	write := func(t *testing.T, dir, path string) {
+		t.Helper()
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("message: x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		paths   []string
+		files   []string
+		want    []ReleaseMove
+		wantErr string
+	}{
+		{
+			name:  "new version folder",
+			files: []string{"changelog/unreleased/kong/fix.yml", "changelog/unreleased/kong/README.md"},
+			want:  []ReleaseMove{{From: "changelog/unreleased/kong/fix.yml", To: "changelog/3.14.0.9/kong/fix.yml"}},
+		},
+		{
+			name:  "existing version folder",
+			files: []string{"changelog/unreleased/kong/fix.yml", "changelog/3.14.0.9/kong/older.yml"},
+			want:  []ReleaseMove{{From: "changelog/unreleased/kong/fix.yml", To: "changelog/3.14.0.9/kong/fix.yml"}},
+		},
+		{
+			name:    "file already released",
+			files:   []string{"changelog/unreleased/kong/fix.yml", "changelog/3.14.0.9/kong/fix.yml"},
+			wantErr: "already exists",
+		},
+		{
+			name:    "same destination twice",
+			paths:   []string{"changelog/unreleased/kong", "changelog/unreleased/kong/"},
+			files:   []string{"changelog/unreleased/kong/fix.yml"},
+			wantErr: "is also released to",
+		},
+		{
+			name:  "missing changelog path",
+			files: []string{"README.md"},
+			want:  []ReleaseMove{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tc.files {
+				write(t, dir, file)
+			}
+
+			paths := []string{filepath.FromSlash("changelog/unreleased/kong")}
+			if tc.paths != nil {
+				paths = nil
+				for _, path := range tc.paths {
+					paths = append(paths, filepath.FromSlash(path))
+				}
+			}
+			got, err := ReleaseMoves(dir, paths, "3.14.0.9")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("ReleaseMoves() = %+v, want %+v", got, tc.want)
+			}
+			for i, move := range tc.want {
+				if got[i].From != filepath.FromSlash(move.From) || got[i].To != filepath.FromSlash(move.To) {
+					t.Fatalf("ReleaseMoves() = %+v, want %+v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	for _, version := range []string{"3.14.0.8", "3.14.0.9"} {
+		if err := WriteRelease(path, "## "+version+"\n\n- Fixed.\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## 3.14.0.9\n\n- Fixed.\n\n## 3.14.0.8\n\n- Fixed.\n\n"; string(content) != want {
+		t.Fatalf("CHANGELOG.md = %q, want %q", content, want)
+	}
+}
+
+func TestApplyReleaseMovesRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	commitFile(t, dir, "changelog/unreleased/kong/a.yml", "message: a\n", "a")
+	commitFile(t, dir, "changelog/unreleased/kong/b.yml", "message: b\n", "b")
+
+	moves := []ReleaseMove{
+		{From: filepath.FromSlash("changelog/unreleased/kong/a.yml"), To: filepath.FromSlash("changelog/3.14.0.9/kong/a.yml")},
+		{From: filepath.FromSlash("changelog/unreleased/kong/missing.yml"), To: filepath.FromSlash("changelog/3.14.0.9/kong/missing.yml")},
+		{From: filepath.FromSlash("changelog/unreleased/kong/b.yml"), To: filepath.FromSlash("changelog/3.14.0.9/kong/b.yml")},
+	}
+	if err := ApplyReleaseMoves(dir, moves); err == nil || !strings.Contains(err.Error(), "missing.yml") {
+		t.Fatalf("err = %v, want the failed move", err)
+	}
+
+	if status := runGit(t, dir, "status", "--porcelain", "--untracked-files=all"); status != "" {
+		t.Fatalf("moves not rolled back:\n%s", status)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "changelog", "3.14.0.9")); !os.IsNotExist(err) {
+		t.Fatalf("version folder left behind: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package changelog
+
+import (
+	"embed"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:generate cp -f ../../changelog-markdown.tmpl changelog-markdown.tmpl
+//go:embed changelog-markdown.tmpl
+var changelogTmplFS embed.FS
+
+// Render executes the template selected by Options.TemplatePath, the embedded
+// changelog-markdown.tmpl by default, on data and writes the result to w.
+func (g *Generator) Render(w io.Writer, data *TemplateData) error {
+	name := "changelog-markdown.tmpl"
+	if g.options.TemplatePath != "" {
+		name = filepath.Base(g.options.TemplatePath)
+	}
+
+	tmpl := template.New(name).Funcs(template.FuncMap{
+		"arr": func(values ...any) []any { return values },
+		"dict": func(values ...any) (map[string]any, error) {
+			if len(values)%2 != 0 {
+				return nil, errors.New("invalid dictionary call")
+			}
+
+			root := make(map[string]any)
+
+			for i := 0; i < len(values); i += 2 {
+				dict := root
+				var key string
+				switch v := values[i].(type) {
+				case string:
+					key = v
+				case []string:
+					for i := 0; i < len(v)-1; i++ {
+						key = v[i]
+						var m map[string]any
+						v, found := dict[key]
+						if found {
+							m = v.(map[string]any)
+						} else {
+							m = make(map[string]any)
+							dict[key] = m
+						}
+						dict = m
+					}
+					key = v[len(v)-1]
+				default:
+					return nil, errors.New("invalid dictionary key")
+				}
+				dict[key] = values[i+1]
+			}
+
+			return root, nil
+		},
+		"trim": func(value string) string {
+			return strings.TrimSpace(value)
+		},
+		"join": func(values []string, sep string) string {
+			return strings.Join(values, sep)
+		},
+	})
+
+	var err error
+	if g.options.TemplatePath != "" {
+		tmpl, err = tmpl.ParseFiles(g.options.TemplatePath)
+	} else {
+		tmpl, err = tmpl.ParseFS(changelogTmplFS, "changelog-markdown.tmpl")
+	}
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
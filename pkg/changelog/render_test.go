@@ -0,0 +1,62 @@
+package changelog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderCustomTemplate(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	secured := commitFile(t, dir, "changelog/unreleased/kong/secured.yml", "message: Fixed a bypass.\ntype: bugfix\nscope: Core\n", "fix: bypass (#10)")
+	plain := commitFile(t, dir, "changelog/unreleased/kong/plain.yml", "message: Fixed a typo.\ntype: bugfix\nscope: Core\n", "fix: typo (#11)")
+
+	mergedAt := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	resolver := fakeResolver{
+		secured: {
+			Number: 10, Title: "fix: bypass", URL: "https://github.com/Kong/kong/pull/10", MergedAt: mergedAt,
+			Author: Contributor{Login: "alice"}, Labels: []string{"security", "backport"}, BaseBranch: "next/3.14.x.x", MergeCommitSHA: secured,
+		},
+		plain: {
+			Number: 11, Title: "fix: typo", URL: "https://github.com/Kong/kong/pull/11", MergedAt: mergedAt,
+			Author: Contributor{Login: "bob"}, Labels: []string{"docs"}, BaseBranch: "master", MergeCommitSHA: plain,
+		},
+	}
+
+	templatePath := filepath.Join(t.TempDir(), "security.tmpl")
+	template := `{{ range $scope := index .Type "bugfix" }}{{ range $entry := $scope.Entries }}` +
+		`{{ with $entry.PullRequest }}{{ if .HasLabel "security" }}` +
+		`- {{ $entry.Message }} [#{{ .Number }}]({{ .URL }}) by @{{ .Author.Login }}, merged into {{ .BaseBranch }} on {{ .MergedAt.Format "2006-01-02" }} as {{ slice .MergeCommitSHA 0 7 }} ({{ join .Labels ", " }})
+{{ end }}{{ end }}{{ end }}{{ end }}`
+	if err := os.WriteFile(templatePath, []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	generator := NewGenerator(Options{
+		Title:           "Kong",
+		RepoPath:        dir,
+		ChangelogPaths:  []string{"changelog/unreleased/kong"},
+		GithubIssueRepo: "Kong/kong",
+		TemplatePath:    templatePath,
+	}, DefaultConfig(), resolver, nil)
+
+	data, failures, err := generator.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("failures = %+v, want none", failures)
+	}
+
+	var out bytes.Buffer
+	if err := generator.Render(&out, data); err != nil {
+		t.Fatal(err)
+	}
+	want := "- Fixed a bypass. [#10](https://github.com/Kong/kong/pull/10) by @alice, merged into next/3.14.x.x on 2024-05-02 as " + secured[:7] + " (security, backport)\n"
+	if out.String() != want {
+		t.Fatalf("rendered %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,72 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+)
+
+// revertedBy returns the commit that reverted the change behind entry on the
+// branch being generated for, with the reason, or "" when it was not reverted
+// (or was reapplied since). The attributed commit, the commit adding the entry
+// on the ref (which differs when attributed through a source branch) and the
+// PR merge commit are looked up by their `git revert` trailer, and the PR by
+// the subject of a revert PR, in the revert index of the ref.
+func (g *Generator) revertedBy(entry *ChangelogEntry) (string, string) {
+	ref := g.options.ToRef
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	commits := []string{entry.CommitSHA}
+	if entry.originalCommitSHA != "" && !contains(commits, entry.originalCommitSHA) {
+		commits = append(commits, entry.originalCommitSHA)
+	}
+	if entry.PullRequest != nil && entry.PullRequest.MergeCommitSHA != "" && !contains(commits, entry.PullRequest.MergeCommitSHA) {
+		commits = append(commits, entry.PullRequest.MergeCommitSHA)
+	}
+	for _, commit := range commits {
+		if revert := g.attribution.history.FindRevert(ref, commit); revert != "" {
+			return revert, fmt.Sprintf("commit %s was reverted by %s", commit, revert)
+		}
+	}
+
+	if entry.PullRequest != nil && entry.PullRequest.Number != 0 {
+		if revert := g.attribution.history.FindPullRequestRevert(ref, entry.PullRequest.Number); revert != "" {
+			return revert, fmt.Sprintf("PR #%d was reverted by %s", entry.PullRequest.Number, revert)
+		}
+	}
+
+	return "", ""
+}
+
+// Reverted returns the entries the last Collect found to be reverted, in
+// processing order.
+func (g *Generator) Reverted() []SkippedEntry {
+	return g.revertedEntries
+}
+
+// keepsReverted reports whether reverted entries are kept in the output.
+func (g *Generator) keepsReverted() bool {
+	return g.options.Reverted != RevertedOmit
+}
+
+func (g *Generator) writeRevertedSummary(w io.Writer) {
+	if len(g.revertedEntries) == 0 {
+		return
+	}
+
+	entryNoun := "entries"
+	if len(g.revertedEntries) == 1 {
+		entryNoun = "entry"
+	}
+
+	action := "omitted"
+	if g.keepsReverted() {
+		action = "included"
+	}
+
+	fmt.Fprintf(w, "\n%s %d reverted changelog %s:\n", action, len(g.revertedEntries), entryNoun)
+	for i, reverted := range g.revertedEntries {
+		fmt.Fprintf(w, "%d. file: %s\n   reason: %s\n", i+1, reverted.FileName, reverted.Reason)
+	}
+}
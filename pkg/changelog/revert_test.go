@@ -0,0 +1,141 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// commitChange commits a code change to kong/<name>.lua along with the
+// changelog entry at path, and returns the commit.
+func commitChange(t *testing.T, dir, name, path, content, msg string) string {
+	t.Helper()
+	code := filepath.Join("kong", name+".lua")
+	if err := os.MkdirAll(filepath.Join(dir, "kong"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, code), []byte("return 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", code)
+	return commitFile(t, dir, path, content, msg)
+}
+
+// revertKeepingEntry reverts commit but keeps the changelog entry at path it
+// added, as when the entry is only reworded or dropped later, and returns the
+// revert commit.
+func revertKeepingEntry(t *testing.T, dir, commit, path string) string {
+	t.Helper()
+	runGit(t, dir, "revert", "--no-commit", commit)
+	runGit(t, dir, "checkout", commit, "--", path)
+	runGit(t, dir, "commit", "--no-gpg-sign", "--no-edit")
+	return strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+}
+
+func TestGeneratorReverted(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	commitFile(t, dir, "README.md", "base\n", "init")
+	upstreamBranch := strings.TrimSpace(runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+	runGit(t, dir, "branch", "next")
+	pickedEntry := "changelog/unreleased/kong/picked.yml"
+	upstream := commitChange(t, dir, "flag", pickedEntry, "message: Added a flag.\ntype: feature\n", "feat: flag (#1)")
+
+	// The release branch backports the upstream feature, which is attributed to
+	// the upstream commit through the source branch, then reverts its local
+	// cherry-pick; it also reverts one of its own fixes.
+	runGit(t, dir, "checkout", "-q", "next")
+	// The backport subject does not name the PR, so only the trailer of the
+	// revert ties it to the entry.
+	runGit(t, dir, "cherry-pick", "--no-commit", upstream)
+	runGit(t, dir, "commit", "--no-gpg-sign", "-m", "backport flag\n\n(cherry picked from commit "+upstream+")")
+	picked := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	pickedRevert := revertKeepingEntry(t, dir, picked, pickedEntry)
+	leakEntry := "changelog/unreleased/kong/leak.yml"
+	leak := commitChange(t, dir, "leak", leakEntry, "message: Fixed a leak.\ntype: bugfix\n", "fix: leak (#2)")
+	leakRevert := revertKeepingEntry(t, dir, leak, leakEntry)
+	fresh := commitFile(t, dir, "changelog/unreleased/kong/fresh.yml", "message: Fixed a crash.\ntype: bugfix\n", "fix: crash (#3)")
+
+	resolver := fakeResolver{
+		upstream: {Number: 1, Title: "feat: flag", MergedAt: time.Now(), MergeCommitSHA: upstream},
+		leak:     {Number: 2, Title: "fix: leak", MergedAt: time.Now(), MergeCommitSHA: leak},
+		fresh:    {Number: 3, Title: "fix: crash", MergedAt: time.Now(), MergeCommitSHA: fresh},
+	}
+	wantReasons := map[string]string{
+		"picked.yml": "commit " + picked + " was reverted by " + pickedRevert,
+		"leak.yml":   "commit " + leak + " was reverted by " + leakRevert,
+	}
+
+	tests := []struct {
+		reverted string
+		// want lists the collected entries, mapped to their Reverted.
+		want map[string]string
+	}{
+		{
+			reverted: RevertedOmit,
+			want:     map[string]string{"fresh.yml": ""},
+		},
+		{
+			reverted: RevertedWarn,
+			want:     map[string]string{"fresh.yml": "", "picked.yml": "", "leak.yml": ""},
+		},
+		{
+			reverted: RevertedNote,
+			want:     map[string]string{"fresh.yml": "", "picked.yml": pickedRevert, "leak.yml": leakRevert},
+		},
+		{
+			// The default keeps the reverted entries with a warning.
+			reverted: "",
+			want:     map[string]string{"fresh.yml": "", "picked.yml": "", "leak.yml": ""},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.reverted, func(t *testing.T) {
+			generator := NewGenerator(Options{
+				Title:           "Kong",
+				RepoPath:        dir,
+				ChangelogPaths:  []string{"changelog/unreleased/kong"},
+				GithubIssueRepo: "Kong/kong",
+				SourceBranches:  []string{upstreamBranch},
+				Reverted:        tc.reverted,
+			}, DefaultConfig(), resolver, nil)
+
+			data, failures, err := generator.Collect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(failures) != 0 {
+				t.Fatalf("failures = %+v, want none", failures)
+			}
+
+			reverted := generator.Reverted()
+			if len(reverted) != len(wantReasons) {
+				t.Fatalf("reverted = %+v, want %d entries", reverted, len(wantReasons))
+			}
+			for _, entry := range reverted {
+				if want := wantReasons[filepath.Base(entry.FileName)]; entry.Reason != want {
+					t.Fatalf("%s reverted because %q, want %q", filepath.Base(entry.FileName), entry.Reason, want)
+				}
+			}
+
+			got := make(map[string]string)
+			for _, scopes := range data.Type {
+				for _, scope := range scopes {
+					for _, entry := range scope.Entries {
+						got[filepath.Base(entry.fileName)] = entry.Reverted
+					}
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("collected %v, want %v", got, tc.want)
+			}
+			for file, revert := range tc.want {
+				if g, ok := got[file]; !ok || g != revert {
+					t.Fatalf("collected %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
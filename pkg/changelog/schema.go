@@ -0,0 +1,110 @@
+package changelog
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonSchema is the subset of JSON Schema (draft-07) JSONSchema writes, with
+// its keys in a fixed order so the schema reads like a hand-written one.
+type jsonSchema struct {
+	Schema               string            `json:"$schema,omitempty"`
+	Type                 string            `json:"type,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	MinLength            int               `json:"minLength,omitempty"`
+	MaxLength            int               `json:"maxLength,omitempty"`
+	Pattern              string            `json:"pattern,omitempty"`
+	Enum                 []string          `json:"enum,omitempty"`
+	Examples             []any             `json:"examples,omitempty"`
+	Const                string            `json:"const,omitempty"`
+	Items                *jsonSchema       `json:"items,omitempty"`
+	Properties           *schemaProperties `json:"properties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+	AdditionalProperties *bool             `json:"additionalProperties,omitempty"`
+	If                   *jsonSchema       `json:"if,omitempty"`
+	Then                 *jsonSchema       `json:"then,omitempty"`
+}
+
+// schemaProperties are the properties of an object schema, in order.
+type schemaProperties []schemaProperty
+
+type schemaProperty struct {
+	name   string
+	schema *jsonSchema
+}
+
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, property := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(property.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(property.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// labelsSchema is the schema of a list of editions or products: the declared
+// ones, or any strings, such as examples, when the config declares none.
+func labelsSchema(description string, allowed []string, examples ...any) *jsonSchema {
+	items := &jsonSchema{Type: "string", Enum: allowed}
+	if len(allowed) == 0 {
+		items.Examples = examples
+	}
+	return &jsonSchema{Type: "array", Description: description, Items: items}
+}
+
+// JSONSchema returns the JSON Schema of the entry files for editors and
+// schema checkers, derived from the rules of ValidateFile with the types,
+// scopes, editions and products of the config. changelog-schema.json is
+// that of DefaultConfig.
+func (c *Config) JSONSchema() ([]byte, error) {
+	noAdditional := false
+	schema := &jsonSchema{
+		Schema: "http://json-schema.org/draft-07/schema#",
+		Type:   "object",
+		Properties: &schemaProperties{
+			{"message", &jsonSchema{Type: "string", Description: "Message of the changelog", MinLength: 1, MaxLength: maxMessageLength}},
+			{"type", &jsonSchema{Type: "string", Description: "Changelog type", Enum: c.Types}},
+			{"scope", &jsonSchema{Type: "string", Description: "Changelog scope", Enum: c.Scopes}},
+			{"prs", &jsonSchema{Type: "array", Description: "List of associated GitHub PRs", Items: &jsonSchema{Type: "integer", Examples: []any{1001, 1002}}}},
+			{"githubs", &jsonSchema{Type: "array", Description: "List of associated GitHub references for both PR and issue", Items: &jsonSchema{Type: "integer", Examples: []any{1001, 1002}}}},
+			{"jiras", &jsonSchema{Type: "array", Description: "List of associated Jira tickets for internal tracking.", Items: &jsonSchema{Type: "string", Pattern: jiraPattern.String()}}},
+			{"editions", labelsSchema("Editions the change applies to, as declared in the changelog config. Omit when it applies to all editions.", c.Editions, "OSS", "Enterprise")},
+			{"products", labelsSchema("Products the change applies to, as declared in the changelog config. Omit when it applies to all products.", c.Products, "Konnect")},
+		},
+		Required:             []string{"message", "type"},
+		AdditionalProperties: &noAdditional,
+		If: &jsonSchema{
+			Required:   []string{"scope"},
+			Properties: &schemaProperties{{"scope", &jsonSchema{Const: pluginScope}}},
+		},
+		Then: &jsonSchema{
+			Properties: &schemaProperties{{"message", &jsonSchema{
+				Pattern:     pluginPrefixPattern.String(),
+				Description: `When scope is Plugin, message must start with one or more comma-separated plugin names in bold, e.g. "**rate-limiting** ..." or "**kafka-upstream**, **confluent**: ...". An optional "**<X> Only**. " prefix (e.g. "**Konnect Only**. ") may precede the plugin names.`,
+			}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
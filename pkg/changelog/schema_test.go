@@ -0,0 +1,49 @@
+package changelog
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestJSONSchemaMatchesSchemaFile(t *testing.T) {
+	config := DefaultConfig()
+	got, err := config.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("../../changelog-schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("changelog-schema.json is out of date with the validation rules, run make schema")
+	}
+}
+
+func TestJSONSchemaDeclaredEditions(t *testing.T) {
+	config := DefaultConfig()
+	config.Editions = []string{"OSS", "Enterprise"}
+	content, err := config.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Items struct {
+				Enum     []string `json:"enum"`
+				Examples []any    `json:"examples"`
+			} `json:"items"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if editions := schema.Properties["editions"].Items; len(editions.Enum) != 2 || editions.Examples != nil {
+		t.Fatalf("editions items = %+v, want the declared editions", editions)
+	}
+	if products := schema.Properties["products"].Items; products.Enum != nil || len(products.Examples) == 0 {
+		t.Fatalf("products items = %+v, want any product", products)
+	}
+}
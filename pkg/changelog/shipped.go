@@ -0,0 +1,86 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Kong/changelog/utils"
+)
+
+// shippedIndex answers whether an entry was already part of a previous
+// release, identified by Options.Since.
+type shippedIndex struct {
+	tag string
+	// blobs maps the blob IDs of the changelog files present at tag to their
+	// paths.
+	blobs map[string]string
+}
+
+// SkippedEntry is an entry found to be already shipped, reverted or a
+// duplicate, with the reason.
+type SkippedEntry struct {
+	FileName string
+	Reason   string
+}
+
+func loadShippedIndex(repoPath, tag string) (*shippedIndex, error) {
+	blobs, err := utils.BlobsAt(repoPath, tag, "changelog")
+	if err != nil {
+		return nil, err
+	}
+	return &shippedIndex{tag: tag, blobs: blobs}, nil
+}
+
+// shippedReason explains why the entry read from filePath with content was
+// already shipped at the tag of idx, or returns "" when it was not. An entry
+// was shipped when a changelog file with the same content is present at the
+// tag (wherever it lives, e.g. in a version folder), or when the commit that
+// introduced it, or the commit it was cherry-picked from, is reachable from
+// the tag.
+func (g *Generator) shippedReason(idx *shippedIndex, filePath string, content []byte) string {
+	if path, ok := idx.blobs[utils.BlobHash(g.options.RepoPath, content)]; ok {
+		return fmt.Sprintf("same content as %s at %s", path, idx.tag)
+	}
+
+	commit, err := g.attribution.history.FindOriginalCommit(g.options.ToRef, filePath)
+	if err != nil {
+		return ""
+	}
+	if utils.IsAncestor(g.options.RepoPath, commit, idx.tag) {
+		return fmt.Sprintf("commit %s is already in %s", commit, idx.tag)
+	}
+
+	if src := g.attribution.history.FindCherryPickSource(commit); src != "" && utils.IsAncestor(g.options.RepoPath, src, idx.tag) {
+		return fmt.Sprintf("commit %s was cherry-picked from %s, which is already in %s", commit, src, idx.tag)
+	}
+
+	return ""
+}
+
+// Shipped returns the entries the last Collect found to be already shipped in
+// Options.Since, in processing order.
+func (g *Generator) Shipped() []SkippedEntry {
+	return g.shippedEntries
+}
+
+func (g *Generator) writeShippedSummary(w io.Writer) {
+	if len(g.shippedEntries) == 0 {
+		return
+	}
+
+	entryNoun := "entries"
+	if len(g.shippedEntries) == 1 {
+		entryNoun = "entry"
+	}
+
+	action := "omitted"
+	if g.options.Shipped == ShippedFlag {
+		action = "included"
+	}
+
+	fmt.Fprintf(w, "\n%s %d changelog %s already shipped in %s:\n", action, len(g.shippedEntries), entryNoun, g.options.Since)
+	for i, shipped := range g.shippedEntries {
+		fmt.Fprintf(w, "%d. file: %s\n   reason: %s\n", i+1, shipped.FileName, strings.ReplaceAll(shipped.Reason, "\n", "\n   "))
+	}
+}
@@ -0,0 +1,106 @@
+package changelog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneratorShipped(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	commitFile(t, dir, "README.md", "base\n", "init")
+
+	// The 3.14.0.8 release is tagged on the main line, after the release
+	// branch was cut, with a released entry in its version folder.
+	ancestor := commitFile(t, dir, "changelog/unreleased/kong/ancestor.yml", "message: Fixed a crash.\ntype: bugfix\n", "fix: crash (#1)")
+	runGit(t, dir, "branch", "next")
+	upstream := commitFile(t, dir, "changelog/unreleased/kong/picked.yml", "message: Added a flag.\ntype: feature\n", "feat: flag (#2)")
+	commitFile(t, dir, "changelog/3.14.0.8/kong/released.yml", "message: Released before.\ntype: bugfix\n", "release 3.14.0.8")
+	runGit(t, dir, "tag", "3.14.0.8")
+
+	// The release branch rewords the entries it shares with the tag, so that
+	// only their commits tell they shipped, and copies the released one.
+	runGit(t, dir, "checkout", "-q", "next")
+	commitFile(t, dir, "changelog/unreleased/kong/ancestor.yml", "message: Fixed a crash on start.\ntype: bugfix\n", "reword")
+	runGit(t, dir, "cherry-pick", "-x", upstream)
+	picked := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	commitFile(t, dir, "changelog/unreleased/kong/picked.yml", "message: Added a flag to start.\ntype: feature\n", "reword")
+	copied := commitFile(t, dir, "changelog/unreleased/kong/copied.yml", "message: Released before.\ntype: bugfix\n", "fix: copy (#3)")
+	fresh := commitFile(t, dir, "changelog/unreleased/kong/fresh.yml", "message: Fixed a leak.\ntype: bugfix\n", "fix: leak (#4)")
+
+	resolver := fakeResolver{
+		ancestor: {Number: 1, Title: "fix: crash", MergedAt: time.Now(), MergeCommitSHA: ancestor},
+		picked:   {Number: 2, Title: "feat: flag", MergedAt: time.Now(), MergeCommitSHA: picked},
+		copied:   {Number: 3, Title: "fix: copy", MergedAt: time.Now(), MergeCommitSHA: copied},
+		fresh:    {Number: 4, Title: "fix: leak", MergedAt: time.Now(), MergeCommitSHA: fresh},
+	}
+	wantReasons := map[string]string{
+		"ancestor.yml": "commit " + ancestor + " is already in 3.14.0.8",
+		"picked.yml":   "commit " + picked + " was cherry-picked from " + upstream + ", which is already in 3.14.0.8",
+		"copied.yml":   "same content as changelog/3.14.0.8/kong/released.yml at 3.14.0.8",
+	}
+
+	tests := []struct {
+		shipped string
+		// want lists the collected entries, mapped to their ShippedIn.
+		want map[string]string
+	}{
+		{
+			shipped: ShippedOmit,
+			want:    map[string]string{"fresh.yml": ""},
+		},
+		{
+			shipped: ShippedFlag,
+			want:    map[string]string{"fresh.yml": "", "ancestor.yml": "3.14.0.8", "picked.yml": "3.14.0.8", "copied.yml": "3.14.0.8"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.shipped, func(t *testing.T) {
+			generator := NewGenerator(Options{
+				Title:           "Kong",
+				RepoPath:        dir,
+				ChangelogPaths:  []string{"changelog/unreleased/kong"},
+				GithubIssueRepo: "Kong/kong",
+				Since:           "3.14.0.8",
+				Shipped:         tc.shipped,
+			}, DefaultConfig(), resolver, nil)
+
+			data, failures, err := generator.Collect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(failures) != 0 {
+				t.Fatalf("failures = %+v, want none", failures)
+			}
+
+			shipped := generator.Shipped()
+			if len(shipped) != len(wantReasons) {
+				t.Fatalf("shipped = %+v, want %d entries", shipped, len(wantReasons))
+			}
+			for _, entry := range shipped {
+				if want := wantReasons[filepath.Base(entry.FileName)]; entry.Reason != want {
+					t.Fatalf("%s shipped because %q, want %q", filepath.Base(entry.FileName), entry.Reason, want)
+				}
+			}
+
+			got := make(map[string]string)
+			for _, scopes := range data.Type {
+				for _, scope := range scopes {
+					for _, entry := range scope.Entries {
+						got[filepath.Base(entry.fileName)] = entry.ShippedIn
+					}
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("collected %v, want %v", got, tc.want)
+			}
+			for file, shippedIn := range tc.want {
+				if g, ok := got[file]; !ok || g != shippedIn {
+					t.Fatalf("collected %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const maxMessageLength = 1000
+
+var jiraPattern = regexp.MustCompile(`^[A-Z]+-[0-9]+$`)
+
+// entryKeys are the keys of an entry file, in the order of the README.
+var entryKeys = []string{"message", "type", "scope", "prs", "githubs", "jiras", "editions", "products"}
+
+// Diagnostic is a rule an entry file breaks, located at the line and column
+// of the offending key or value.
+type Diagnostic struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Field is the entry key the diagnostic is about, or "" for the whole
+	// entry.
+	Field string `json:"field,omitempty"`
+	// Rule is the name of the LintRule the diagnostic is about, or "" for a
+	// validation rule.
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+	// Suggestion is the likely intended value (or key, for an unknown key),
+	// or "" when there is none.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// String formats the diagnostic as file:line:column: message, followed by
+// the suggestion and the lint rule if any.
+func (d Diagnostic) String() string {
+	message := d.Message
+	if d.Suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %q?)", d.Suggestion)
+	}
+	if d.Rule != "" {
+		message += fmt.Sprintf(" [%s]", d.Rule)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, message)
+}
+
+// configDiagnostics returns the diagnostics of diagnostics about the
+// config-driven fields, which are checked even without Options.Strict.
+func configDiagnostics(diagnostics []Diagnostic) []Diagnostic {
+	filtered := make([]Diagnostic, 0)
+	for _, d := range diagnostics {
+		if d.Field == "editions" || d.Field == "products" {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// ValidationError is the error of an entry that breaks some rules.
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		lines = append(lines, d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// entryValidator collects the diagnostics of one entry file.
+type entryValidator struct {
+	config      *Config
+	file        string
+	diagnostics []Diagnostic
+}
+
+func (v *entryValidator) report(node *yaml.Node, field, suggestion, format string, args ...any) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		File:       v.file,
+		Line:       node.Line,
+		Column:     node.Column,
+		Field:      field,
+		Message:    fmt.Sprintf(format, args...),
+		Suggestion: suggestion,
+	})
+}
+
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// ValidateFile checks the entry file content read from file against the
+// rules of the entry format (see JSONSchema), with the allowed types, scopes,
+// editions and products taken from the config. Every violation is reported, located in
+// the file, in the order of the file.
+func (c *Config) ValidateFile(file string, content []byte) []Diagnostic {
+	v := &entryValidator{config: c, file: file}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		line := 1
+		if match := yamlErrorLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		v.report(&yaml.Node{Line: line, Column: 1}, "", "", "invalid YAML: %s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return v.diagnostics
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		v.report(&yaml.Node{Line: 1, Column: 1}, "", "", "entry is empty, it must be a mapping with message and type keys")
+		return v.diagnostics
+	}
+
+	v.validateMapping(doc.Content[0])
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		a, b := v.diagnostics[i], v.diagnostics[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return v.diagnostics
+}
+
+func (v *entryValidator) validateMapping(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		v.report(node, "", "", "entry must be a mapping with message and type keys")
+		return
+	}
+
+	values := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !contains(entryKeys, key.Value) {
+			v.report(key, "", suggest(key.Value, entryKeys, nil), "unknown key %q, must be one of: %s", key.Value, strings.Join(entryKeys, ", "))
+			continue
+		}
+		if _, ok := values[key.Value]; ok {
+			v.report(key, key.Value, "", "duplicate key %q", key.Value)
+			continue
+		}
+		values[key.Value] = value
+	}
+
+	cfg := v.config
+	for _, required := range []string{"message", "type"} {
+		if values[required] == nil {
+			v.report(node, required, "", "%s is required", required)
+		}
+	}
+	if value := values["message"]; value != nil {
+		v.validateMessage(value, values["scope"])
+	}
+	if value := values["type"]; value != nil {
+		v.validateLabel(value, "type", cfg.Types, cfg.Inference.Types)
+	}
+	if value := values["scope"]; value != nil {
+		v.validateLabel(value, "scope", cfg.Scopes, cfg.Inference.TitleScopes)
+	}
+	for _, field := range []string{"prs", "githubs"} {
+		if value := values[field]; value != nil {
+			v.validateNumbers(value, field)
+		}
+	}
+	if value := values["jiras"]; value != nil {
+		v.validateJiras(value)
+	}
+	if value := values["editions"]; value != nil {
+		v.validateLabels(value, "editions", "edition", cfg.Editions)
+	}
+	if value := values["products"]; value != nil {
+		v.validateLabels(value, "products", "product", cfg.Products)
+	}
+}
+
+// isString reports whether node is a scalar that decodes to a string.
+func isString(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str"
+}
+
+func (v *entryValidator) validateMessage(node, scope *yaml.Node) {
+	if !isString(node) {
+		v.report(node, "message", "", "message must be a string")
+		return
+	}
+	if strings.TrimSpace(node.Value) == "" {
+		v.report(node, "message", "", "message is required")
+		return
+	}
+	if length := utf8.RuneCountInString(node.Value); length > maxMessageLength {
+		v.report(node, "message", "", "message must be at most %d characters, got %d", maxMessageLength, length)
+	}
+
+	if scope == nil || scope.Value != pluginScope || pluginPrefixPattern.MatchString(node.Value) {
+		return
+	}
+	hint := ""
+	if strings.HasPrefix(node.Value, `"`) || strings.HasPrefix(node.Value, "'") {
+		hint = "; the message starts with a quote, which is part of the content in a block scalar (| or >)"
+	}
+	v.report(node, "message", "", `scope is Plugin, so message must start with the plugin names in bold, e.g. "**rate-limiting** Fixed ..." or "**kafka-upstream**, **confluent**: Added ..."%s`, hint)
+}
+
+func (v *entryValidator) validateLabel(node *yaml.Node, field string, allowed []string, aliases map[string]string) {
+	if !isString(node) {
+		v.report(node, field, "", "%s must be a string", field)
+		return
+	}
+	if !contains(allowed, node.Value) {
+		v.report(node, field, suggest(node.Value, allowed, aliases), "unknown %s %q, must be one of: %s", field, node.Value, strings.Join(allowed, ", "))
+	}
+}
+
+func (v *entryValidator) validateNumbers(node *yaml.Node, field string) {
+	if node.Kind != yaml.SequenceNode {
+		v.report(node, field, "", "%s must be a list of numbers, e.g. [1001, 1002]", field)
+		return
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode || item.ShortTag() != "!!int" {
+			suggestion := ""
+			if no := strings.TrimPrefix(item.Value, "#"); item.Kind == yaml.ScalarNode && no != item.Value {
+				if _, err := strconv.Atoi(no); err == nil {
+					suggestion = no
+				}
+			}
+			v.report(item, field, suggestion, "%s entry %q must be a number", field, item.Value)
+		}
+	}
+}
+
+func (v *entryValidator) validateJiras(node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode {
+		v.report(node, "jiras", "", `jiras must be a list of Jira ticket IDs, e.g. ["FTI-1234"]`)
+		return
+	}
+	for _, item := range node.Content {
+		if isString(item) && jiraPattern.MatchString(item.Value) {
+			continue
+		}
+		suggestion := strings.ToUpper(strings.TrimSpace(item.Value))
+		if !jiraPattern.MatchString(suggestion) {
+			suggestion = ""
+		}
+		v.report(item, "jiras", suggestion, "jira %q must look like a Jira ticket ID, e.g. FTI-1234", item.Value)
+	}
+}
+
+// validateLabels checks a list of editions or products, against allowed when
+// the config declares some.
+func (v *entryValidator) validateLabels(node *yaml.Node, field, label string, allowed []string) {
+	if node.Kind != yaml.SequenceNode {
+		example := strings.Join(allowed, ", ")
+		if example == "" {
+			example = "Enterprise"
+		}
+		v.report(node, field, "", "%s must be a list, e.g. [%s]", field, example)
+		return
+	}
+	for _, item := range node.Content {
+		if len(allowed) == 0 {
+			if !isString(item) {
+				v.report(item, field, "", "%s %q must be a string", label, item.Value)
+			}
+			continue
+		}
+		if !isString(item) || !contains(allowed, item.Value) {
+			v.report(item, field, suggest(item.Value, allowed, nil), "unknown %s %q, must be one of: %s", label, item.Value, strings.Join(allowed, ", "))
+		}
+	}
+}
+
+// suggest returns the value of allowed that value was most likely meant to
+// be: the one it equals but for case, the one aliases maps it to (by lower
+// case), or the closest one within a few typos. It returns "" when there is
+// no such value.
+func suggest(value string, allowed []string, aliases map[string]string) string {
+	if match := sameOrAlias(value, allowed, aliases); match != "" {
+		return match
+	}
+
+	lower := strings.ToLower(strings.TrimSpace(value))
+	best, bestDistance := "", len(lower)/3+1
+	candidates := append([]string(nil), allowed...)
+	sort.Strings(candidates)
+	for _, a := range candidates {
+		if d := editDistance(lower, strings.ToLower(a)); d <= bestDistance && (best == "" || d < bestDistance) {
+			best, bestDistance = a, d
+		}
+	}
+	return best
+}
+
+// sameOrAlias returns the value of allowed that value equals but for case
+// and surrounding spaces, or that aliases maps it to (by lower case), or ""
+// when there is none.
+func sameOrAlias(value string, allowed []string, aliases map[string]string) string {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	for _, a := range allowed {
+		if strings.ToLower(a) == lower {
+			return a
+		}
+	}
+	if alias, ok := aliases[lower]; ok && contains(allowed, alias) {
+		return alias
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
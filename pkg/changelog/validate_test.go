@@ -0,0 +1,105 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFile(t *testing.T) {
+	config := DefaultConfig()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "valid",
+			content: "message: Fixed an issue\ntype: bugfix\nscope: Core\nprs: [12]\njiras: [\"FTI-12\"]\n",
+			want:    nil,
+		},
+		{
+			name:    "unknown key",
+			content: "message: Fixed an issue\ntype: bugfix\nScope: Core\n",
+			want:    []string{`entry.yml:3:1: unknown key "Scope", must be one of: message, type, scope, prs, githubs, jiras, editions, products (did you mean "scope"?)`},
+		},
+		{
+			name:    "type alias",
+			content: "message: Fixed an issue\ntype: fix\n",
+			want:    []string{`entry.yml:2:7: unknown type "fix", must be one of: feature, bugfix, dependency, deprecation, breaking_change, performance (did you mean "bugfix"?)`},
+		},
+		{
+			name:    "scope case",
+			content: "message: Fixed an issue\ntype: bugfix\nscope: core\n",
+			want:    []string{`entry.yml:3:8: unknown scope "core", must be one of: Core, Plugin, PDK, Admin API, Performance, Configuration, Clustering, Portal, CLI Command (did you mean "Core"?)`},
+		},
+		{
+			name:    "scope typo",
+			content: "message: Fixed an issue\ntype: bugfix\nscope: Clustring\n",
+			want:    []string{`entry.yml:3:8: unknown scope "Clustring", must be one of: Core, Plugin, PDK, Admin API, Performance, Configuration, Clustering, Portal, CLI Command (did you mean "Clustering"?)`},
+		},
+		{
+			name:    "lower case jira",
+			content: "message: Fixed an issue\ntype: bugfix\njiras:\n  - FTI-1\n  - fti-12\n",
+			want:    []string{`entry.yml:5:5: jira "fti-12" must look like a Jira ticket ID, e.g. FTI-1234 (did you mean "FTI-12"?)`},
+		},
+		{
+			name:    "pr with hash",
+			content: "message: Fixed an issue\ntype: bugfix\nprs: [\"#12\"]\n",
+			want:    []string{`entry.yml:3:7: prs entry "#12" must be a number (did you mean "12"?)`},
+		},
+		{
+			name:    "plugin message without plugin names",
+			content: "message: Fixed an issue\ntype: bugfix\nscope: Plugin\n",
+			want:    []string{`entry.yml:1:10: scope is Plugin, so message must start with the plugin names in bold, e.g. "**rate-limiting** Fixed ..." or "**kafka-upstream**, **confluent**: Added ..."`},
+		},
+		{
+			name:    "plugin message",
+			content: "message: \"**acl**: Fixed an issue\"\ntype: bugfix\nscope: Plugin\n",
+			want:    nil,
+		},
+		{
+			name:    "missing required keys",
+			content: "scope: Core\n",
+			want:    []string{"entry.yml:1:1: message is required", "entry.yml:1:1: type is required"},
+		},
+		{
+			name:    "invalid YAML",
+			content: "message: Fixed\ntype: bugfix\n  scope: Core\n",
+			want:    []string{"entry.yml:3:1: invalid YAML: line 3: mapping values are not allowed in this context"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, d := range config.ValidateFile("entry.yml", []byte(tc.content)) {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("ValidateFile() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	allowed := []string{"feature", "bugfix", "performance"}
+	aliases := map[string]string{"fix": "bugfix", "perf": "performance"}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "Feature", want: "feature"},
+		{value: "perf", want: "performance"},
+		{value: "bugfx", want: "bugfix"},
+		{value: "docs", want: ""},
+	}
+
+	for _, tc := range tests {
+		if got := suggest(tc.value, allowed, aliases); got != tc.want {
+			t.Errorf("suggest(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -64,6 +66,20 @@ func gitPrefix(workingDir string) string {
 	return normalizePath(strings.TrimSpace(string(output)))
 }
 
+// gitObjectFormat returns the hash algorithm of the object IDs of the
+// repository of workingDir, sha1 or sha256. Versions of git predating SHA-256
+// repositories do not know the option, and only have SHA-1 ones.
+func gitObjectFormat(workingDir string) string {
+	cmd := exec.Command("git", "rev-parse", "--show-object-format")
+	cmd.Dir = workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "sha1"
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
 func trimGitPrefix(filename, prefix string) string {
 	name := normalizePath(filename)
 	prefix = normalizePath(prefix)
@@ -120,10 +136,19 @@ func findRenameSource(workingDir, commit, filename string) string {
 	return ""
 }
 
+// revArgs returns rev as git arguments, or none for "" (i.e. HEAD).
+func revArgs(rev string) []string {
+	if rev == "" {
+		return nil
+	}
+	return []string{rev}
+}
+
 // findAddedCommit finds the commit where the file was first added.
-func findAddedCommit(workingDir, filename string) string {
-	cmd := exec.Command("git", "log", "--diff-filter=A", "--no-show-signature",
-		"--pretty=format:%H", "--", filename)
+func findAddedCommit(workingDir, rev, filename string) string {
+	args := append([]string{"log"}, revArgs(rev)...)
+	args = append(args, "--diff-filter=A", "--no-show-signature", "--pretty=format:%H", "--", filename)
+	cmd := exec.Command("git", args...)
 	cmd.Dir = workingDir
 	output, err := cmd.Output()
 	if err != nil {
@@ -138,8 +163,10 @@ func findAddedCommit(workingDir, filename string) string {
 }
 
 // findOldestCommit returns the oldest commit that touched the file.
-func findOldestCommit(workingDir, filename string) string {
-	cmd := exec.Command("git", "log", "--no-show-signature", "--pretty=format:%H", "--", filename)
+func findOldestCommit(workingDir, rev, filename string) string {
+	args := append([]string{"log"}, revArgs(rev)...)
+	args = append(args, "--no-show-signature", "--pretty=format:%H", "--", filename)
+	cmd := exec.Command("git", args...)
 	cmd.Dir = workingDir
 	output, err := cmd.Output()
 	if err != nil {
@@ -153,6 +180,43 @@ func findOldestCommit(workingDir, filename string) string {
 	return strings.TrimSpace(lines[len(lines)-1])
 }
 
+// CommitMessage returns the full message of the given commit.
+func CommitMessage(workingDir, commit string) (string, error) {
+	return repositoryAt(workingDir).CommitMessage(commit)
+}
+
+// CommitMessagesBetween returns the full messages of the commits reachable
+// from head but not from base (`git log base..head`), newest first.
+func CommitMessagesBetween(workingDir, base, head string) ([]string, error) {
+	cmd := exec.Command("git", "log", "--no-show-signature", "--format=%B%x00", base+".."+head, "--")
+	cmd.Dir = workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the commits between %s and %s: %v", base, head, err)
+	}
+
+	messages := make([]string, 0)
+	for _, message := range strings.Split(string(output), "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+// CurrentBranch returns the name of the branch checked out in workingDir, or
+// "" when HEAD is detached or cannot be resolved.
+func CurrentBranch(workingDir string) string {
+	cmd := exec.Command("git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	cmd.Dir = workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
 // FindCherryPickSource returns the source commit SHA recorded by
 // `git cherry-pick -x` in the given commit's message, or "" when the commit is
 // not a recorded cherry-pick (or cannot be read locally).
@@ -165,19 +229,7 @@ func findOldestCommit(workingDir, filename string) string {
 // trailer is the most recent hop and identifies the immediate source, so that
 // is the one returned.
 func FindCherryPickSource(workingDir, commit string) string {
-	cmd := exec.Command("git", "log", "-1", "--no-show-signature", "--format=%B", commit)
-	cmd.Dir = workingDir
-	output, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-
-	matches := cherryPickTrailerPattern.FindAllStringSubmatch(string(output), -1)
-	if len(matches) == 0 {
-		return ""
-	}
-
-	return matches[len(matches)-1][1]
+	return repositoryAt(workingDir).FindCherryPickSource(commit)
 }
 
 // FindCherryPickOnBranch returns the SHA of the most recent commit reachable
@@ -251,11 +303,31 @@ func FindFileOriginOnBranch(workingDir, branch, file string) string {
 	return addedCommitOnBranch(workingDir, branch, ":(top,glob)changelog/**/"+base)
 }
 
+// MoveFile renames src to dst with `git mv`, so the move is staged and the
+// entry's history can be followed across it. The parent directory of dst must
+// exist.
+func MoveFile(workingDir, src, dst string) error {
+	cmd := exec.Command("git", "mv", "--", src, dst)
+	cmd.Dir = workingDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to move %s to %s: %v: %s", src, dst, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// AddFile stages path with `git add`.
+func AddFile(workingDir, path string) error {
+	cmd := exec.Command("git", "add", "--", path)
+	cmd.Dir = workingDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage %s: %v: %s", path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // RefExists reports whether ref resolves to a commit in the repository.
 func RefExists(workingDir, ref string) bool {
-	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
-	cmd.Dir = workingDir
-	return cmd.Run() == nil
+	return repositoryAt(workingDir).RefExists(ref)
 }
 
 // IsAncestor reports whether commit is an ancestor of (i.e. reachable from) ref.
@@ -263,27 +335,65 @@ func RefExists(workingDir, ref string) bool {
 // callers should validate ref with RefExists first when a missing ref must not
 // be treated as "not an ancestor".
 func IsAncestor(workingDir, commit, ref string) bool {
-	cmd := exec.Command("git", "merge-base", "--is-ancestor", commit, ref)
-	cmd.Dir = workingDir
-	return cmd.Run() == nil
+	return repositoryAt(workingDir).IsAncestor(commit, ref)
+}
+
+// FindMergeCommit returns the merge commit on the first-parent history of ref
+// that brought commit in, or "" when commit is itself on that history (e.g. a
+// squash merge) or is not reachable from ref.
+func FindMergeCommit(workingDir, commit, ref string) string {
+	revList := func(args ...string) []string {
+		cmd := exec.Command("git", append([]string{"rev-list"}, args...)...)
+		cmd.Dir = workingDir
+		output, err := cmd.Output()
+		if err != nil {
+			return nil
+		}
+		return strings.Fields(string(output))
+	}
+
+	mainline := make(map[string]bool)
+	for _, sha := range revList("--first-parent", commit+".."+ref) {
+		mainline[sha] = true
+	}
+
+	// The oldest mainline merge descending from commit merged it, unless its
+	// first parent already contained commit (then commit is on the mainline
+	// itself and every later merge merely descends from it).
+	for _, merge := range revList("--ancestry-path", "--merges", "--reverse", commit+".."+ref) {
+		if !mainline[merge] {
+			continue
+		}
+		if IsAncestor(workingDir, commit, merge+"^1") {
+			return ""
+		}
+		return merge
+	}
+	return ""
 }
 
 // FindOriginalCommit traces back through renames to find
 // the commit that originally created the changelog file.
 func FindOriginalCommit(workingDir, filename string) (string, error) {
-	return findOriginalCommit(workingDir, filename, make(map[string]bool))
+	return findOriginalCommit(workingDir, "", filename, make(map[string]bool))
 }
 
-func findOriginalCommit(workingDir, filename string, visited map[string]bool) (string, error) {
+// FindOriginalCommitAt is FindOriginalCommit for the history of rev rather
+// than of HEAD, so a file can be traced without checking rev out.
+func FindOriginalCommitAt(workingDir, rev, filename string) (string, error) {
+	return findOriginalCommit(workingDir, rev, filename, make(map[string]bool))
+}
+
+func findOriginalCommit(workingDir, rev, filename string, visited map[string]bool) (string, error) {
 	key := normalizePath(pathRelativeToWorkingDir(workingDir, filename))
 	if visited[key] {
 		return "", fmt.Errorf("cycle detected for %s", filename)
 	}
 	visited[key] = true
 
-	commit := findAddedCommit(workingDir, filename)
+	commit := findAddedCommit(workingDir, rev, filename)
 	if commit == "" {
-		commit = findOldestCommit(workingDir, filename)
+		commit = findOldestCommit(workingDir, rev, filename)
 		if commit == "" {
 			return "", &NoCommitsFoundError{FileName: filename}
 		}
@@ -294,9 +404,293 @@ func findOriginalCommit(workingDir, filename string, visited map[string]bool) (s
 		return commit, nil
 	}
 
-	result, err := findOriginalCommit(workingDir, oldName, visited)
+	result, err := findOriginalCommit(workingDir, rev, oldName, visited)
 	if err != nil || result == "" {
 		return commit, nil
 	}
 	return result, nil
 }
+
+// ChangedFiles returns the paths, relative to the repository root, that the
+// given commit changed compared to its first parent.
+func ChangedFiles(workingDir, commit string) ([]string, error) {
+	cmd := exec.Command("git", "diff-tree", "-r", "--no-commit-id", "--name-only",
+		"--root", "-m", "--first-parent", commit)
+	cmd.Dir = workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed by %s: %v", commit, err)
+	}
+
+	files := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// FilesChangedBetween returns the files under paths that were added or
+// modified between the from and to revisions, relative to workingDir. Renames
+// are reported as additions of the new path.
+func FilesChangedBetween(workingDir, from, to string, paths []string) ([]string, error) {
+	args := []string{"diff", "--name-only", "--relative", "--no-renames", "--diff-filter=AM", from, to, "--"}
+	args = append(args, paths...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed between %s and %s: %v", from, to, err)
+	}
+
+	files := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, normalizePath(line))
+		}
+	}
+	return files, nil
+}
+
+// ReadFileAt returns the content of file, relative to workingDir, as of rev.
+func ReadFileAt(workingDir, rev, file string) ([]byte, error) {
+	return repositoryAt(workingDir).ReadFileAt(rev, file)
+}
+
+// BlobHash returns the git object ID content would have as a blob in the
+// repository of workingDir, so a file read outside of git can be compared with
+// the blobs of a tree.
+func BlobHash(workingDir string, content []byte) string {
+	return repositoryAt(workingDir).BlobHash(content)
+}
+
+// BlobsAt returns the blob IDs of the files under path (relative to the
+// repository root) in the tree of rev, mapped to their paths.
+func BlobsAt(workingDir, rev, path string) (map[string]string, error) {
+	return repositoryAt(workingDir).BlobsAt(rev, path)
+}
+
+// ListFilesAt returns the files under paths in the tree of rev, relative to
+// workingDir.
+func ListFilesAt(workingDir, rev string, paths []string) ([]string, error) {
+	return repositoryAt(workingDir).ListFilesAt(rev, paths)
+}
+
+// patchIDs runs the output of the given git command through
+// `git patch-id --stable` and returns the patch IDs mapped to their commits.
+// When several commits share a patch ID, the first one listed is kept.
+func patchIDs(workingDir string, args ...string) (map[string]string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = workingDir
+	diff, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read diff for patch-id: %v", err)
+	}
+
+	cmd = exec.Command("git", "patch-id", "--stable")
+	cmd.Dir = workingDir
+	cmd.Stdin = bytes.NewReader(diff)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute patch-id: %v", err)
+	}
+
+	// output format: <patch-id> <commit>
+	ids := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if _, ok := ids[fields[0]]; !ok {
+			ids[fields[0]] = fields[1]
+		}
+	}
+	return ids, nil
+}
+
+// PatchID returns the stable patch ID (see git-patch-id(1)) of the changes
+// commit made, limited to paths when given, or "" when it changed nothing
+// there. Two commits applying the same change have the same patch ID, whether
+// they were cherry-picked with or without -x, or applied with `git am`.
+func PatchID(workingDir, commit string, paths ...string) (string, error) {
+	args := append([]string{"show", "--no-color", "--no-ext-diff", "--format=commit %H", commit, "--"}, paths...)
+	ids, err := patchIDs(workingDir, args...)
+	if err != nil {
+		return "", err
+	}
+	for id := range ids {
+		return id, nil
+	}
+	return "", nil
+}
+
+// PatchIDsOnBranch returns the patch IDs of the non-merge commits reachable
+// from branch but not from exclude, limited to paths when given, mapped to
+// the most recent commit with each ID.
+func PatchIDsOnBranch(workingDir, branch, exclude string, paths ...string) (map[string]string, error) {
+	args := []string{"log", "-p", "--no-merges", "--no-color", "--no-ext-diff", "--format=commit %H", branch}
+	if exclude != "" {
+		args = append(args, "^"+exclude)
+	}
+	args = append(append(args, "--"), paths...)
+	return patchIDs(workingDir, args...)
+}
+
+// FilePatchIDsOnBranch returns the patch IDs of the changes each non-merge
+// commit reachable from branch but not from exclude made to each file under
+// paths, keyed by the file path relative to the repository root and mapped to
+// the most recent commit with each ID. The patch ID of a file's change equals
+// PatchID of the commit limited to that file; all of them are read with one
+// `git log -p` and one `git patch-id`.
+func FilePatchIDsOnBranch(workingDir, branch, exclude string, paths ...string) (map[string]map[string]string, error) {
+	args := []string{"log", "-p", "--no-merges", "--no-color", "--no-ext-diff", "--no-renames",
+		"--src-prefix=a/", "--dst-prefix=b/", "--format=commit %H", branch}
+	if exclude != "" {
+		args = append(args, "^"+exclude)
+	}
+	args = append(append(args, "--"), paths...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = workingDir
+	diff, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read diff for patch-id: %v", err)
+	}
+
+	// Split the diff into one patch per commit and file. Each patch is given
+	// to git patch-id under a made-up commit ID, its index in changes, as
+	// patch-id only reads hexadecimal IDs.
+	type fileChange struct{ commit, file string }
+	changes := make([]fileChange, 0)
+	var input bytes.Buffer
+	commit, inHunk := "", false
+	for _, line := range strings.SplitAfter(string(diff), "\n") {
+		switch {
+		case strings.HasPrefix(line, "commit "):
+			commit = strings.TrimSpace(strings.TrimPrefix(line, "commit "))
+			continue
+		case strings.HasPrefix(line, "diff --git "):
+			changes = append(changes, fileChange{commit: commit})
+			fmt.Fprintf(&input, "commit %040x\n", len(changes)-1)
+			inHunk = false
+		case len(changes) == 0:
+			continue
+		case strings.HasPrefix(line, "@@ "):
+			inHunk = true
+		case !inHunk && (strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ")):
+			if file := diffHeaderPath(line[4:]); file != "" {
+				changes[len(changes)-1].file = file
+			}
+		}
+		input.WriteString(line)
+	}
+
+	cmd = exec.Command("git", "patch-id", "--stable")
+	cmd.Dir = workingDir
+	cmd.Stdin = &input
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute patch-id: %v", err)
+	}
+
+	// output format: <patch-id> <index>
+	ids := make(map[string]map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		index, err := strconv.ParseUint(fields[1], 16, 64)
+		if err != nil || index >= uint64(len(changes)) || changes[index].file == "" {
+			continue
+		}
+		change := changes[index]
+		if ids[change.file] == nil {
+			ids[change.file] = make(map[string]string)
+		}
+		if _, ok := ids[change.file][fields[0]]; !ok {
+			ids[change.file][fields[0]] = change.commit
+		}
+	}
+	return ids, nil
+}
+
+// diffHeaderPath returns the path of a "--- a/<path>" or "+++ b/<path>" diff
+// header, or "" for /dev/null.
+func diffHeaderPath(name string) string {
+	name = strings.TrimRight(name, "\t\n")
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+	if name == "/dev/null" || len(name) < 2 {
+		return ""
+	}
+	return name[2:]
+}
+
+// grepCommits returns the commits reachable from ref, newest first, whose
+// message matches the given `git log` grep arguments.
+func grepCommits(workingDir, ref string, grepArgs ...string) []string {
+	args := append([]string{"log", "--no-show-signature", "--format=%H"}, grepArgs...)
+	args = append(args, revArgs(ref)...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(output))
+}
+
+// FindRevert returns the commit reachable from ref that reverted commit (its
+// message says "This reverts commit <commit>", as written by `git revert`), or
+// "" when there is none or every such revert was itself reverted, i.e. the
+// change was reapplied.
+func FindRevert(workingDir, ref, commit string) string {
+	return findRevert(workingDir, ref, commit, make(map[string]bool))
+}
+
+func findRevert(workingDir, ref, commit string, visited map[string]bool) string {
+	if commit == "" || visited[commit] {
+		return ""
+	}
+	visited[commit] = true
+
+	for _, revert := range grepCommits(workingDir, ref, "--fixed-strings", "--grep=This reverts commit "+commit) {
+		if findRevert(workingDir, ref, revert, visited) == "" {
+			return revert
+		}
+	}
+	return ""
+}
+
+// FindPullRequestRevert returns the commit reachable from ref whose subject
+// reverts pull request number, as GitHub's "Revert" button names it
+// (`Revert "<title> (#<number>)"`), or "" when there is none or the revert was
+// itself reverted.
+func FindPullRequestRevert(workingDir, ref string, number int) string {
+	pattern := fmt.Sprintf(`^Revert ".*\(#%d\)`, number)
+	for _, revert := range grepCommits(workingDir, ref, "--extended-regexp", "--grep="+pattern) {
+		message, err := CommitMessage(workingDir, revert)
+		if err != nil {
+			continue
+		}
+
+		// `Revert "Revert "..."` reapplies the PR rather than reverting it.
+		depth := 0
+		for subject := message; strings.HasPrefix(subject, `Revert "`); subject = subject[len(`Revert "`):] {
+			depth++
+		}
+		if depth%2 == 0 {
+			continue
+		}
+
+		if FindRevert(workingDir, ref, revert) == "" {
+			return revert
+		}
+	}
+	return ""
+}
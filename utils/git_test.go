@@ -207,3 +207,298 @@ func TestIsAncestor(t *testing.T) {
 		t.Error("unknown commit should not be reported as an ancestor")
 	}
 }
+
+func TestChangedFiles(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "README", "base\n", "base")
+	sha := commitFile(t, dir, "kong/plugins/acl/handler.lua", "return {}\n", "fix(plugins/acl): thing")
+
+	// Paths are relative to the repository root, even from a subdirectory.
+	got, err := ChangedFiles(filepath.Join(dir, "kong"), sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "kong/plugins/acl/handler.lua" {
+		t.Fatalf("ChangedFiles() = %q, want [kong/plugins/acl/handler.lua]", got)
+	}
+
+	if _, err := ChangedFiles(dir, "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"); err == nil {
+		t.Fatal("ChangedFiles() for unknown commit should fail")
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	dir := newRepo(t)
+	base := commitWithMessage(t, dir, "base")
+	runGit(t, dir, "checkout", "-q", "-b", "fix/FTI-1234-acl")
+
+	if got := CurrentBranch(dir); got != "fix/FTI-1234-acl" {
+		t.Fatalf("CurrentBranch() = %q, want %q", got, "fix/FTI-1234-acl")
+	}
+
+	runGit(t, dir, "checkout", "-q", base)
+	if got := CurrentBranch(dir); got != "" {
+		t.Fatalf("CurrentBranch() on detached HEAD = %q, want \"\"", got)
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: fixed\n", "fix: thing (#1)")
+
+	if err := os.MkdirAll(filepath.Join(dir, "changelog/3.14.0.9/kong"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := MoveFile(dir, "changelog/unreleased/kong/fix.yml", "changelog/3.14.0.9/kong/fix.yml"); err != nil {
+		t.Fatalf("MoveFile() = %v", err)
+	}
+
+	status := strings.TrimSpace(runGit(t, dir, "status", "--porcelain"))
+	if status != "R  changelog/unreleased/kong/fix.yml -> changelog/3.14.0.9/kong/fix.yml" {
+		t.Fatalf("git status after MoveFile() = %q, want a staged rename", status)
+	}
+
+	if err := MoveFile(dir, "changelog/unreleased/kong/absent.yml", "changelog/3.14.0.9/kong/absent.yml"); err == nil {
+		t.Fatal("MoveFile() of an untracked file should fail")
+	}
+}
+
+func TestFilesChangedBetween(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "changelog/unreleased/kong/old.yml", "message: old\n", "old")
+	runGit(t, dir, "tag", "3.14.0.8")
+	commitFile(t, dir, "changelog/unreleased/kong/old.yml", "message: reworded\n", "reword")
+	commitFile(t, dir, "changelog/unreleased/kong/new.yml", "message: new\n", "new")
+	commitFile(t, dir, "kong/init.lua", "return {}\n", "code")
+	runGit(t, dir, "branch", "next")
+
+	got, err := FilesChangedBetween(dir, "3.14.0.8", "next", []string{"changelog/unreleased/kong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"changelog/unreleased/kong/new.yml", "changelog/unreleased/kong/old.yml"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("FilesChangedBetween() = %q, want %q", got, want)
+	}
+
+	// From a subdirectory, paths are relative to it.
+	got, err = FilesChangedBetween(filepath.Join(dir, "changelog"), "3.14.0.8", "next", []string{"unreleased/kong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != filepath.FromSlash("unreleased/kong/new.yml") {
+		t.Fatalf("FilesChangedBetween(subdir) = %q", got)
+	}
+}
+
+func TestReadFileAt(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: first\n", "first")
+	runGit(t, dir, "tag", "v1")
+	commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: second\n", "second")
+
+	got, err := ReadFileAt(filepath.Join(dir, "changelog"), "v1", "unreleased/kong/fix.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "message: first\n" {
+		t.Fatalf("ReadFileAt() = %q, want the content at v1", got)
+	}
+
+	if _, err := ReadFileAt(dir, "v1", "absent.yml"); err == nil {
+		t.Fatal("ReadFileAt() of an absent file should fail")
+	}
+}
+
+func TestFindOriginalCommitAt(t *testing.T) {
+	dir := newRepo(t)
+	base := commitFile(t, dir, "README", "base\n", "base")
+	runGit(t, dir, "checkout", "-q", "-b", "next")
+	added := commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: fixed\n", "fix: thing (#1)")
+	runGit(t, dir, "checkout", "-q", base)
+
+	// The file only exists on next, which is not checked out.
+	if _, err := FindOriginalCommit(dir, "changelog/unreleased/kong/fix.yml"); err == nil {
+		t.Fatal("FindOriginalCommit() should not see a file absent from HEAD's history")
+	}
+	got, err := FindOriginalCommitAt(dir, "next", "changelog/unreleased/kong/fix.yml")
+	if err != nil || got != added {
+		t.Fatalf("FindOriginalCommitAt() = %q, %v, want %q", got, err, added)
+	}
+}
+
+func TestBlobsAt(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "changelog/3.14.0.8/kong/fix.yml", "message: fixed\n", "release 3.14.0.8")
+	commitFile(t, dir, "kong/init.lua", "return {}\n", "code")
+	runGit(t, dir, "tag", "3.14.0.8")
+
+	// Even from a subdirectory, path is relative to the repository root.
+	blobs, err := BlobsAt(filepath.Join(dir, "kong"), "3.14.0.8", "changelog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 1 {
+		t.Fatalf("BlobsAt() = %v, want only the changelog file", blobs)
+	}
+
+	// BlobHash matches git's object ID for the same content.
+	if got := blobs[BlobHash(dir, []byte("message: fixed\n"))]; got != "changelog/3.14.0.8/kong/fix.yml" {
+		t.Fatalf("BlobsAt()[BlobHash(content)] = %q, want changelog/3.14.0.8/kong/fix.yml", got)
+	}
+}
+
+func TestListFilesAt(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "changelog/unreleased/kong/a.yml", "message: a\n", "a")
+	runGit(t, dir, "branch", "next")
+	commitFile(t, dir, "changelog/unreleased/kong/b.yml", "message: b\n", "b")
+
+	got, err := ListFilesAt(filepath.Join(dir, "changelog"), "next", []string{"unreleased/kong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != filepath.FromSlash("unreleased/kong/a.yml") {
+		t.Fatalf("ListFilesAt() = %q, want [unreleased/kong/a.yml]", got)
+	}
+}
+
+func TestPatchIDOnBranch(t *testing.T) {
+	dir := newRepo(t)
+	base := commitFile(t, dir, "README", "base\n", "base")
+
+	// The minor branch gets the change as one commit, plus an unrelated one.
+	runGit(t, dir, "checkout", "-q", "-b", "minor", base)
+	onMinor := commitFile(t, dir, "kong/init.lua", "return {}\n", "fix: thing (#1)")
+	commitFile(t, dir, "kong/other.lua", "return 1\n", "feat: other (#2)")
+
+	// The fix-release branch re-applies it without a cherry-pick trailer.
+	runGit(t, dir, "checkout", "-q", "-b", "fix", base)
+	reapplied := commitFile(t, dir, "kong/init.lua", "return {}\n", "[backport] fix: thing")
+
+	id, err := PatchID(dir, reapplied)
+	if err != nil || id == "" {
+		t.Fatalf("PatchID() = %q, %v", id, err)
+	}
+
+	ids, err := PatchIDsOnBranch(dir, "minor", "fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("PatchIDsOnBranch() = %v, want the 2 commits of minor after the fork", ids)
+	}
+	if got := ids[id]; got != onMinor {
+		t.Fatalf("PatchIDsOnBranch()[PatchID(reapplied)] = %q, want %q", got, onMinor)
+	}
+
+	// Limited to a path the commit did not change, there is no patch.
+	if id, err := PatchID(dir, reapplied, "changelog"); err != nil || id != "" {
+		t.Fatalf("PatchID(unchanged path) = %q, %v, want \"\"", id, err)
+	}
+}
+
+func TestFilePatchIDsOnBranch(t *testing.T) {
+	dir := newRepo(t)
+	base := commitFile(t, dir, "README", "base\n", "base")
+	entry := "changelog/unreleased/kong/fix a thing.yml"
+
+	// The minor branch gets the entry together with the code change, plus an
+	// unrelated entry.
+	runGit(t, dir, "checkout", "-q", "-b", "minor", base)
+	if err := os.WriteFile(filepath.Join(dir, "init.lua"), []byte("return {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "init.lua")
+	onMinor := commitFile(t, dir, entry, "message: Fixed a thing.\n", "fix: thing (#1)")
+	other := commitFile(t, dir, "changelog/unreleased/kong/other.yml", "message: Other.\n", "feat: other (#2)")
+
+	// The fix-release branch re-applies only the entry, by hand.
+	runGit(t, dir, "checkout", "-q", "-b", "fix", base)
+	reapplied := commitFile(t, dir, entry, "message: Fixed a thing.\n", "[backport] fix: thing")
+
+	ids, err := FilePatchIDsOnBranch(dir, "minor", "fix", "changelog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || len(ids[entry]) != 1 || len(ids["changelog/unreleased/kong/other.yml"]) != 1 {
+		t.Fatalf("FilePatchIDsOnBranch() = %v, want one patch for each entry", ids)
+	}
+
+	id, err := PatchID(dir, reapplied, entry)
+	if err != nil || id == "" {
+		t.Fatalf("PatchID() = %q, %v", id, err)
+	}
+	if got := ids[entry][id]; got != onMinor {
+		t.Fatalf("FilePatchIDsOnBranch()[entry][PatchID(reapplied)] = %q, want %q", got, onMinor)
+	}
+	id, _ = PatchID(dir, other, "changelog/unreleased/kong/other.yml")
+	if got := ids["changelog/unreleased/kong/other.yml"][id]; got != other {
+		t.Fatalf("FilePatchIDsOnBranch()[other.yml][PatchID(other)] = %q, want %q", got, other)
+	}
+}
+
+func TestFindMergeCommit(t *testing.T) {
+	dir := newRepo(t)
+	base := commitFile(t, dir, "README.md", "base\n", "init")
+	main := strings.TrimSpace(runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+
+	// A PR branch adds the entry and another commit, then is merged with a
+	// merge commit.
+	runGit(t, dir, "checkout", "-q", "-b", "feature", base)
+	added := commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: fix\n", "add entry")
+	commitFile(t, dir, "kong/init.lua", "return {}\n", "fix thing")
+	runGit(t, dir, "checkout", "-q", main)
+	squashed := commitFile(t, dir, "kong/other.lua", "return 1\n", "feat: other (#2)")
+	runGit(t, dir, "merge", "--no-ff", "--no-gpg-sign", "-q", "-m", "Merge pull request #1 from someone/feature", "feature")
+	merge := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	// A later, unrelated merge must not be picked for mainline commits.
+	runGit(t, dir, "checkout", "-q", "-b", "later", main)
+	commitFile(t, dir, "kong/later.lua", "return 2\n", "later")
+	runGit(t, dir, "checkout", "-q", main)
+	runGit(t, dir, "merge", "--no-ff", "--no-gpg-sign", "-q", "-m", "Merge pull request #3 from someone/later", "later")
+
+	if got := FindMergeCommit(dir, added, main); got != merge {
+		t.Fatalf("FindMergeCommit(PR branch commit) = %q, want %q", got, merge)
+	}
+	if got := FindMergeCommit(dir, squashed, main); got != "" {
+		t.Fatalf("FindMergeCommit(mainline commit) = %q, want \"\"", got)
+	}
+	if got := FindMergeCommit(dir, merge, main); got != "" {
+		t.Fatalf("FindMergeCommit(merge commit) = %q, want \"\"", got)
+	}
+}
+
+func TestFindRevert(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "README.md", "base\n", "init")
+	main := strings.TrimSpace(runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+
+	fix := commitFile(t, dir, "kong/init.lua", "return {}\n", "fix: thing (#1)")
+	kept := commitFile(t, dir, "kong/other.lua", "return 1\n", "feat: other (#2)")
+	runGit(t, dir, "revert", "--no-edit", fix)
+	revert := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+
+	if got := FindRevert(dir, main, fix); got != revert {
+		t.Fatalf("FindRevert(reverted) = %q, want %q", got, revert)
+	}
+	if got := FindRevert(dir, main, kept); got != "" {
+		t.Fatalf("FindRevert(kept) = %q, want \"\"", got)
+	}
+	if got := FindPullRequestRevert(dir, main, 1); got != revert {
+		t.Fatalf("FindPullRequestRevert(#1) = %q, want %q", got, revert)
+	}
+	if got := FindPullRequestRevert(dir, main, 2); got != "" {
+		t.Fatalf("FindPullRequestRevert(#2) = %q, want \"\"", got)
+	}
+
+	// Reverting the revert reapplies the change.
+	runGit(t, dir, "revert", "--no-edit", revert)
+	if got := FindRevert(dir, main, fix); got != "" {
+		t.Fatalf("FindRevert(reapplied) = %q, want \"\"", got)
+	}
+	if got := FindPullRequestRevert(dir, main, 1); got != "" {
+		t.Fatalf("FindPullRequestRevert(reapplied) = %q, want \"\"", got)
+	}
+}
@@ -0,0 +1,448 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// changelogRoot is the repository-root folder FindFileOriginOnBranch matches
+// basenames under.
+const changelogRoot = "changelog"
+
+var (
+	// revertTrailerPattern matches the line `git revert` records the reverted
+	// commit with.
+	revertTrailerPattern = regexp.MustCompile(`This reverts commit ([0-9a-f]{7,40})`)
+	// revertedPullRequestPattern matches the PR numbers in the subject of a
+	// revert PR.
+	revertedPullRequestPattern = regexp.MustCompile(`\(#(\d+)\)`)
+)
+
+// History answers the per-entry history queries (the commit that added a
+// changelog file, rename sources, cherry-pick trailers and file origins on a
+// branch) from memory. Each ref is indexed on first use by two `git` walks —
+// one `git log --name-status -M` over the changelog paths and one over the
+// commits recording a cherry-pick — instead of several subprocesses per
+// entry, and by a third walk over the revert commits on the first revert
+// query. Queries the index does not cover fall back to the subprocess
+// functions of this package.
+type History struct {
+	workingDir string
+	// prefix is workingDir relative to the repository root; the index keys
+	// paths relative to the root, as git prints them.
+	prefix string
+	// pathspecs are the paths walked, and roots the same paths relative to the
+	// repository root.
+	pathspecs []string
+	roots     []string
+
+	refs map[string]*refHistory
+	// trailers maps every walked commit to the cherry-pick sources its message
+	// records, oldest hop first.
+	trailers map[string][]string
+	// filePatchIDs caches, per branch and excluded ref, the patch IDs of the
+	// changes to the walked paths (see FilePatchIDsOnBranch).
+	filePatchIDs map[string]map[string]map[string]string
+}
+
+type refHistory struct {
+	// added maps a path to the newest commit that added it, and addedTwice the
+	// paths added by more than one commit.
+	added      map[string]string
+	addedTwice map[string]bool
+	// oldest maps a path to the oldest commit that touched it.
+	oldest map[string]string
+	// renames maps a commit to the paths it renamed, new path to old.
+	renames map[string]map[string]string
+	// addedByBase maps the basename of a file under changelogRoot to the newest
+	// commit that added it, and baseAddedTwice the basenames added by more
+	// than one commit.
+	addedByBase    map[string]string
+	baseAddedTwice map[string]bool
+	// cherryPicks maps a recorded cherry-pick source to the commits recording
+	// it, newest first.
+	cherryPicks map[string][]string
+	// picks lists the commits recording a cherry-pick, newest first, for
+	// abbreviated source lookups.
+	picks []string
+	// reverts maps a commit to the commits whose message says they revert
+	// it, and pullRequestReverts a PR number to the commits whose subject
+	// reverts it an odd number of times, newest first. Both are nil until
+	// the first revert query.
+	reverts            map[string][]string
+	pullRequestReverts map[int][]string
+}
+
+// NewHistory returns an empty index of the history of paths (relative to
+// workingDir) and of the repository-root changelog folder.
+func NewHistory(workingDir string, paths []string) *History {
+	prefix := gitPrefix(workingDir)
+	if prefix == "." {
+		prefix = ""
+	}
+
+	h := &History{
+		workingDir:   workingDir,
+		prefix:       prefix,
+		pathspecs:    []string{":(top)" + changelogRoot},
+		roots:        []string{changelogRoot},
+		refs:         make(map[string]*refHistory),
+		trailers:     make(map[string][]string),
+		filePatchIDs: make(map[string]map[string]map[string]string),
+	}
+	for _, p := range paths {
+		root := h.rootPath(p)
+		if h.covers(root) {
+			continue
+		}
+		h.pathspecs = append(h.pathspecs, p)
+		h.roots = append(h.roots, root)
+	}
+	return h
+}
+
+// rootPath returns filename, relative to workingDir (or absolute), relative to
+// the repository root in slash form.
+func (h *History) rootPath(filename string) string {
+	return filepath.ToSlash(filepath.Join(h.prefix, pathRelativeToWorkingDir(h.workingDir, filename)))
+}
+
+func (h *History) covers(root string) bool {
+	for _, r := range h.roots {
+		if root == r || strings.HasPrefix(root, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ref returns the index of ref, walking its history on first use.
+func (h *History) ref(ref string) (*refHistory, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if rh, ok := h.refs[ref]; ok {
+		return rh, nil
+	}
+
+	rh := &refHistory{
+		added:          make(map[string]string),
+		addedTwice:     make(map[string]bool),
+		oldest:         make(map[string]string),
+		renames:        make(map[string]map[string]string),
+		addedByBase:    make(map[string]string),
+		baseAddedTwice: make(map[string]bool),
+		cherryPicks:    make(map[string][]string),
+	}
+
+	// --full-history lists the commits of every side of a merge, so that a
+	// path added on more than one side is known to be, whichever side the
+	// per-file history of git log would follow.
+	args := append([]string{"log", ref, "-M", "--full-history", "--name-status", "--no-show-signature",
+		"--format=%x1e%H%x1f%B%x1f", "--"}, h.pathspecs...)
+	records, err := h.log(args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		commit, changes := record[0], record[2]
+		for _, line := range strings.Split(changes, "\n") {
+			fields := strings.Split(strings.TrimSpace(line), "\t")
+			if len(fields) < 2 || fields[0] == "" {
+				continue
+			}
+
+			file := fields[len(fields)-1]
+			switch status := fields[0][0]; status {
+			case 'A':
+				if _, ok := rh.added[file]; !ok {
+					rh.added[file] = commit
+				} else {
+					rh.addedTwice[file] = true
+				}
+				if strings.HasPrefix(file, changelogRoot+"/") {
+					if _, ok := rh.addedByBase[path.Base(file)]; !ok {
+						rh.addedByBase[path.Base(file)] = commit
+					} else {
+						rh.baseAddedTwice[path.Base(file)] = true
+					}
+				}
+			case 'R':
+				if len(fields) != 3 {
+					continue
+				}
+				if rh.renames[commit] == nil {
+					rh.renames[commit] = make(map[string]string)
+				}
+				rh.renames[commit][file] = fields[1]
+				rh.oldest[fields[1]] = commit
+			}
+			rh.oldest[file] = commit
+		}
+	}
+
+	records, err = h.log("log", ref, "--no-show-signature", "--fixed-strings",
+		"--grep=cherry picked from commit", "--format=%x1e%H%x1f%B%x1f")
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		commit := record[0]
+		rh.picks = append(rh.picks, commit)
+		for _, source := range h.trailers[commit] {
+			rh.cherryPicks[source] = append(rh.cherryPicks[source], commit)
+		}
+	}
+
+	h.refs[ref] = rh
+	return rh, nil
+}
+
+// revertIndex returns the index of ref with its revert commits, walking
+// them on first use.
+func (h *History) revertIndex(ref string) (*refHistory, error) {
+	rh, err := h.ref(ref)
+	if err != nil || rh.reverts != nil {
+		return rh, err
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	records, err := h.log("log", ref, "--no-show-signature", "--grep=This reverts commit",
+		`--grep=^Revert "`, "--format=%x1e%H%x1f%B%x1f")
+	if err != nil {
+		return nil, err
+	}
+
+	rh.reverts = make(map[string][]string)
+	rh.pullRequestReverts = make(map[int][]string)
+	for _, record := range records {
+		commit, message := record[0], record[1]
+		for _, match := range revertTrailerPattern.FindAllStringSubmatch(message, -1) {
+			rh.reverts[match[1]] = append(rh.reverts[match[1]], commit)
+		}
+
+		// `Revert "Revert "..."` reapplies the PR rather than reverting it.
+		subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		depth := 0
+		for ; strings.HasPrefix(subject, `Revert "`); subject = subject[len(`Revert "`):] {
+			depth++
+		}
+		if depth%2 == 0 {
+			continue
+		}
+		for _, match := range revertedPullRequestPattern.FindAllStringSubmatch(subject, -1) {
+			number, _ := strconv.Atoi(match[1])
+			if reverts := rh.pullRequestReverts[number]; len(reverts) == 0 || reverts[len(reverts)-1] != commit {
+				rh.pullRequestReverts[number] = append(reverts, commit)
+			}
+		}
+	}
+	return rh, nil
+}
+
+// log runs `git log` with a "%x1e%H%x1f%B%x1f" format and returns its records
+// as commit, message and the rest of the record (e.g. the name-status lines),
+// recording the cherry-pick trailers of each commit.
+func (h *History) log(args ...string) ([][3]string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = h.workingDir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history (git %s): %v", strings.Join(args[:2], " "), err)
+	}
+
+	records := make([][3]string, 0)
+	for _, raw := range strings.Split(string(output), "\x1e") {
+		parts := strings.SplitN(raw, "\x1f", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		record := [3]string{strings.TrimSpace(parts[0]), parts[1], parts[2]}
+		if _, ok := h.trailers[record[0]]; !ok {
+			sources := make([]string, 0)
+			for _, match := range cherryPickTrailerPattern.FindAllStringSubmatch(record[1], -1) {
+				sources = append(sources, match[1])
+			}
+			h.trailers[record[0]] = sources
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// FindOriginalCommit is FindOriginalCommitAt answered from the index of rev.
+func (h *History) FindOriginalCommit(rev, filename string) (string, error) {
+	root := h.rootPath(filename)
+	if !h.covers(root) {
+		return FindOriginalCommitAt(h.workingDir, rev, filename)
+	}
+
+	rh, err := h.ref(rev)
+	if err != nil {
+		return "", err
+	}
+
+	// Follow renames back like findOriginalCommit: stop at the last commit
+	// found when an older name has no history or was already visited. Which
+	// of several commits adding a path the per-file history finds depends on
+	// how git simplifies the merges of that history, so those paths are left
+	// to it.
+	visited := make(map[string]bool)
+	commit := ""
+	for !visited[root] {
+		visited[root] = true
+		if rh.addedTwice[root] {
+			return FindOriginalCommitAt(h.workingDir, rev, filename)
+		}
+
+		added := rh.added[root]
+		if added == "" {
+			added = rh.oldest[root]
+		}
+		if added == "" {
+			break
+		}
+		commit = added
+
+		oldName, ok := rh.renames[commit][root]
+		if !ok {
+			break
+		}
+		root = oldName
+	}
+	if commit == "" {
+		return "", &NoCommitsFoundError{FileName: filename}
+	}
+	return commit, nil
+}
+
+// FindCherryPickSource is FindCherryPickSource answered from the messages of
+// the walked commits.
+func (h *History) FindCherryPickSource(commit string) string {
+	sources, ok := h.trailers[commit]
+	if !ok {
+		return FindCherryPickSource(h.workingDir, commit)
+	}
+	if len(sources) == 0 {
+		return ""
+	}
+	return sources[len(sources)-1]
+}
+
+// FindCherryPickOnBranch is FindCherryPickOnBranch answered from the index of
+// branch.
+func (h *History) FindCherryPickOnBranch(branch, sourceSHA string) string {
+	rh, err := h.ref(branch)
+	if err != nil {
+		return FindCherryPickOnBranch(h.workingDir, branch, sourceSHA)
+	}
+
+	if commits := rh.cherryPicks[sourceSHA]; len(commits) > 0 {
+		return commits[0]
+	}
+
+	// The trailer may record a longer SHA than the one asked for.
+	for _, commit := range rh.picks {
+		for _, source := range h.trailers[commit] {
+			if strings.HasPrefix(source, sourceSHA) {
+				return commit
+			}
+		}
+	}
+	return ""
+}
+
+// FindFileOriginOnBranch is FindFileOriginOnBranch answered from the index of
+// branch.
+func (h *History) FindFileOriginOnBranch(branch, file string) string {
+	root := h.rootPath(file)
+	if !h.covers(root) {
+		return FindFileOriginOnBranch(h.workingDir, branch, file)
+	}
+
+	rh, err := h.ref(branch)
+	if err != nil {
+		return FindFileOriginOnBranch(h.workingDir, branch, file)
+	}
+
+	if rh.addedTwice[root] {
+		return FindFileOriginOnBranch(h.workingDir, branch, file)
+	}
+	if sha := rh.added[root]; sha != "" {
+		return sha
+	}
+	if rh.baseAddedTwice[path.Base(root)] {
+		return FindFileOriginOnBranch(h.workingDir, branch, file)
+	}
+	return rh.addedByBase[path.Base(root)]
+}
+
+// FindRevert is FindRevert answered from the revert index of ref.
+func (h *History) FindRevert(ref, commit string) string {
+	rh, err := h.revertIndex(ref)
+	if err != nil {
+		return FindRevert(h.workingDir, ref, commit)
+	}
+	return rh.findRevert(commit, make(map[string]bool))
+}
+
+func (rh *refHistory) findRevert(commit string, visited map[string]bool) string {
+	if commit == "" || visited[commit] {
+		return ""
+	}
+	visited[commit] = true
+
+	for _, revert := range rh.reverts[commit] {
+		if rh.findRevert(revert, visited) == "" {
+			return revert
+		}
+	}
+	return ""
+}
+
+// FindPullRequestRevert is FindPullRequestRevert answered from the revert
+// index of ref.
+func (h *History) FindPullRequestRevert(ref string, number int) string {
+	rh, err := h.revertIndex(ref)
+	if err != nil {
+		return FindPullRequestRevert(h.workingDir, ref, number)
+	}
+
+	for _, revert := range rh.pullRequestReverts[number] {
+		if rh.findRevert(revert, make(map[string]bool)) == "" {
+			return revert
+		}
+	}
+	return ""
+}
+
+// FindFilePatchIDOnBranch returns the most recent commit reachable from branch
+// but not from exclude whose change to file has the patch ID id, or "" when
+// there is none. The patch IDs of the changes to the walked paths are computed
+// once per branch.
+func (h *History) FindFilePatchIDOnBranch(branch, exclude, file, id string) (string, error) {
+	root := h.rootPath(file)
+	if !h.covers(root) {
+		ids, err := PatchIDsOnBranch(h.workingDir, branch, exclude, file)
+		return ids[id], err
+	}
+
+	key := branch + " ^" + exclude
+	ids, ok := h.filePatchIDs[key]
+	if !ok {
+		var err error
+		if ids, err = FilePatchIDsOnBranch(h.workingDir, branch, exclude, h.pathspecs...); err != nil {
+			return "", err
+		}
+		h.filePatchIDs[key] = ids
+	}
+	return ids[root][id], nil
+}
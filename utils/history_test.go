@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryMatchesSubprocessQueries(t *testing.T) {
+	dir := newRepo(t)
+	base := commitFile(t, dir, "README.md", "base\n", "init")
+	main := strings.TrimSpace(runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+	src := commitFile(t, dir, "kong/init.lua", "return {}\n", "fix: thing (#1)")
+
+	// The minor branch adds entries, backports src with -x and releases one
+	// entry into a version folder.
+	runGit(t, dir, "checkout", "-q", "-b", "minor", base)
+	added := commitFile(t, dir, "changelog/unreleased/kong/fix.yml", "message: fix\n", "add fix entry")
+	other := commitFile(t, dir, "changelog/unreleased/kong/other.yml", "message: other\n", "add other entry")
+	runGit(t, dir, "cherry-pick", "-x", src)
+	backport := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	runGit(t, dir, "mv", "changelog/unreleased/kong/fix.yml", "changelog/unreleased/kong/fixed.yml")
+	runGit(t, dir, "commit", "-q", "--no-gpg-sign", "-m", "rename entry")
+	commitFile(t, dir, "kong/other.lua", "return 1\n", "unrelated")
+
+	// The same entry is added to minor and, later, upstream, then main is
+	// merged into minor: per file, the merge is unchanged from minor, which
+	// keeps its own commit adding the entry.
+	// Newer commits are listed first by the walks, so the dates are pinned.
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-01T00:00:00Z")
+	backported := commitFile(t, dir, "changelog/unreleased/kong/synced.yml", "message: synced\n", "add synced entry")
+	runGit(t, dir, "checkout", "-q", main)
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-02T00:00:00Z")
+	commitFile(t, dir, "changelog/unreleased/kong/synced.yml", "message: synced\n", "add synced entry upstream")
+	commitFile(t, dir, "changelog/unreleased/kong/upstream.yml", "message: upstream\n", "add upstream entry")
+	runGit(t, dir, "checkout", "-q", "minor")
+	t.Setenv("GIT_COMMITTER_DATE", "2030-01-03T00:00:00Z")
+	runGit(t, dir, "merge", "-q", "--no-ff", "--no-gpg-sign", "-m", "Merge "+main+" into minor", main)
+
+	for _, workingDir := range []string{dir, filepath.Join(dir, "changelog")} {
+		prefix := "changelog/"
+		if workingDir != dir {
+			prefix = ""
+		}
+		h := NewHistory(workingDir, []string{prefix + "unreleased/kong"})
+
+		for _, file := range []string{"unreleased/kong/fixed.yml", "unreleased/kong/other.yml", "unreleased/kong/synced.yml", "unreleased/kong/upstream.yml"} {
+			want, err := FindOriginalCommitAt(workingDir, "minor", prefix+file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := h.FindOriginalCommit("minor", prefix+file); err != nil || got != want {
+				t.Fatalf("FindOriginalCommit(%s) = %q, %v, want %q", file, got, err, want)
+			}
+		}
+		if got, _ := h.FindOriginalCommit("minor", prefix+"unreleased/kong/fixed.yml"); got != added {
+			t.Fatalf("FindOriginalCommit(renamed) = %q, want %q (the commit adding it before the rename)", got, added)
+		}
+		if got, _ := h.FindOriginalCommit("minor", prefix+"unreleased/kong/synced.yml"); got != backported {
+			t.Fatalf("FindOriginalCommit(synced) = %q, want %q (the backport on minor)", got, backported)
+		}
+		if _, err := h.FindOriginalCommit("minor", prefix+"unreleased/kong/absent.yml"); err == nil {
+			t.Fatal("FindOriginalCommit(absent) returned no error")
+		}
+
+		if got := h.FindCherryPickOnBranch("minor", src); got != backport {
+			t.Fatalf("FindCherryPickOnBranch() = %q, want %q", got, backport)
+		}
+		if got := h.FindCherryPickOnBranch("minor", src[:10]); got != backport {
+			t.Fatalf("FindCherryPickOnBranch(abbreviated) = %q, want %q", got, backport)
+		}
+		if got := h.FindCherryPickOnBranch(src, src); got != "" {
+			t.Fatalf("FindCherryPickOnBranch(no backport) = %q, want \"\"", got)
+		}
+		if got := h.FindCherryPickSource(backport); got != src {
+			t.Fatalf("FindCherryPickSource(backport) = %q, want %q", got, src)
+		}
+		if got := h.FindCherryPickSource(added); got != "" {
+			t.Fatalf("FindCherryPickSource(added) = %q, want \"\"", got)
+		}
+
+		for _, file := range []string{"unreleased/kong/other.yml", "3.14.0.1/kong/other.yml", "unreleased/kong/synced.yml", "3.14.0.1/kong/synced.yml", "unreleased/kong/absent.yml"} {
+			want := FindFileOriginOnBranch(workingDir, "minor", prefix+file)
+			if got := h.FindFileOriginOnBranch("minor", prefix+file); got != want {
+				t.Fatalf("FindFileOriginOnBranch(%s) = %q, want %q", file, got, want)
+			}
+		}
+		if got := h.FindFileOriginOnBranch("minor", prefix+"3.14.0.1/kong/other.yml"); got != other {
+			t.Fatalf("FindFileOriginOnBranch(basename) = %q, want %q", got, other)
+		}
+	}
+}
+
+func TestHistoryRevertIndex(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "README.md", "base\n", "init")
+	main := strings.TrimSpace(runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+
+	fix := commitFile(t, dir, "kong/init.lua", "return {}\n", "fix: thing (#1)")
+	kept := commitFile(t, dir, "kong/other.lua", "return 1\n", "feat: other (#2)")
+	runGit(t, dir, "revert", "--no-edit", fix)
+	revert := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD"))
+	// A revert PR merged without the trailer still reverts #2 by its subject.
+	undone := commitFile(t, dir, "kong/other.lua", "return 0\n", `Revert "feat: other (#2)"`)
+
+	h := NewHistory(dir, nil)
+	if got := h.FindRevert(main, fix); got != revert {
+		t.Fatalf("FindRevert(reverted) = %q, want %q", got, revert)
+	}
+	if got := h.FindRevert(main, kept); got != "" {
+		t.Fatalf("FindRevert(kept) = %q, want \"\"", got)
+	}
+	if got := h.FindPullRequestRevert(main, 1); got != revert {
+		t.Fatalf("FindPullRequestRevert(#1) = %q, want %q", got, revert)
+	}
+	if got := h.FindPullRequestRevert(main, 2); got != undone {
+		t.Fatalf("FindPullRequestRevert(#2) = %q, want %q", got, undone)
+	}
+	if got := h.FindPullRequestRevert(main, 3); got != "" {
+		t.Fatalf("FindPullRequestRevert(#3) = %q, want \"\"", got)
+	}
+
+	// Reverting the revert reapplies the change; the new commit is only seen
+	// by a fresh index, as each ref is walked once.
+	runGit(t, dir, "revert", "--no-edit", revert)
+	h = NewHistory(dir, nil)
+	if got := h.FindRevert(main, fix); got != "" {
+		t.Fatalf("FindRevert(reapplied) = %q, want \"\"", got)
+	}
+	if got := h.FindPullRequestRevert(main, 1); got != "" {
+		t.Fatalf("FindPullRequestRevert(reapplied) = %q, want \"\"", got)
+	}
+}
+
+func TestHistoryFindFilePatchIDOnBranch(t *testing.T) {
+	dir := newRepo(t)
+	base := commitFile(t, dir, "README.md", "base\n", "init")
+	entry := "changelog/unreleased/kong/fix.yml"
+
+	runGit(t, dir, "checkout", "-q", "-b", "minor", base)
+	onMinor := commitFile(t, dir, entry, "message: Fixed a thing.\n", "fix: thing (#1)")
+	runGit(t, dir, "checkout", "-q", "-b", "fix", base)
+	reapplied := commitFile(t, dir, entry, "message: Fixed a thing.\n", "[backport] fix: thing")
+
+	id, err := PatchID(dir, reapplied, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workingDir := range []string{dir, filepath.Join(dir, "changelog")} {
+		h := NewHistory(workingDir, nil)
+		file := filepath.Join(dir, filepath.FromSlash(entry))
+		if got, err := h.FindFilePatchIDOnBranch("minor", "fix", file, id); err != nil || got != onMinor {
+			t.Fatalf("FindFilePatchIDOnBranch(%s) = %q, %v, want %q", workingDir, got, err, onMinor)
+		}
+		// Answered from the patch IDs of minor read above.
+		if got, _ := h.FindFilePatchIDOnBranch("minor", "fix", file, "0000"); got != "" {
+			t.Fatalf("FindFilePatchIDOnBranch(unknown ID) = %q, want \"\"", got)
+		}
+		if len(h.filePatchIDs) != 1 {
+			t.Fatalf("patch IDs read for %d branches, want 1", len(h.filePatchIDs))
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Repository reads the objects of the repository containing workingDir
+// through long-lived `git cat-file --batch` and `--batch-check` processes, so
+// that commit messages, files and trees are read without forking git for each
+// lookup. The processes are started on first use; Close stops them.
+type Repository struct {
+	workingDir string
+
+	mu sync.Mutex
+	// prefix is workingDir relative to the repository root, set on first use.
+	prefix *string
+	// objectFormat is the hash algorithm of the object IDs, set on first use.
+	objectFormat *string
+	batch  *catFile
+	check  *catFile
+	// ancestors caches IsAncestor by commit and ref object IDs.
+	ancestors map[[2]string]bool
+}
+
+// OpenRepository returns the Repository of workingDir.
+func OpenRepository(workingDir string) *Repository {
+	return &Repository{
+		workingDir: workingDir,
+		ancestors:  make(map[[2]string]bool),
+	}
+}
+
+var (
+	repositoriesMu sync.Mutex
+	repositories   = make(map[string]*Repository)
+)
+
+// repositoryAt returns the Repository shared by the free functions of this
+// package for workingDir.
+func repositoryAt(workingDir string) *Repository {
+	repositoriesMu.Lock()
+	defer repositoriesMu.Unlock()
+
+	repo, ok := repositories[workingDir]
+	if !ok {
+		repo = OpenRepository(workingDir)
+		repositories[workingDir] = repo
+	}
+	return repo
+}
+
+// CloseRepository stops the git processes of the repository the free
+// functions of this package opened for workingDir, if any.
+func CloseRepository(workingDir string) error {
+	repositoriesMu.Lock()
+	repo, ok := repositories[workingDir]
+	delete(repositories, workingDir)
+	repositoriesMu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return repo.Close()
+}
+
+// CloseRepositories stops the git processes of the repositories opened by the
+// free functions of this package.
+func CloseRepositories() error {
+	repositoriesMu.Lock()
+	defer repositoriesMu.Unlock()
+
+	var firstErr error
+	for workingDir, repo := range repositories {
+		if err := repo.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(repositories, workingDir)
+	}
+	return firstErr
+}
+
+// Close stops the repository's git processes. The repository can still be
+// used afterwards; they are restarted on demand.
+func (r *Repository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var firstErr error
+	for _, process := range []**catFile{&r.batch, &r.check} {
+		if *process == nil {
+			continue
+		}
+		if err := (*process).close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		*process = nil
+	}
+	return firstErr
+}
+
+// catFile is a running `git cat-file --batch` or `--batch-check` process.
+type catFile struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func startCatFile(workingDir, mode string) (*catFile, error) {
+	cmd := exec.Command("git", "cat-file", mode)
+	cmd.Dir = workingDir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start git cat-file %s: %v", mode, err)
+	}
+	return &catFile{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+func (c *catFile) close() error {
+	c.stdin.Close()
+	return c.cmd.Wait()
+}
+
+// objectIDPattern matches a SHA-1 or SHA-256 object ID.
+var objectIDPattern = regexp.MustCompile(`^[0-9a-f]{40}(?:[0-9a-f]{24})?$`)
+
+// object is an object read by cat-file; content is only set in --batch mode.
+type object struct {
+	id      string
+	typ     string
+	content []byte
+}
+
+// errMissingObject reports a name cat-file could not resolve.
+type errMissingObject struct {
+	name string
+}
+
+func (e *errMissingObject) Error() string {
+	return fmt.Sprintf("object %s not found", e.name)
+}
+
+// read looks up name, reading its content when withContent is set.
+func (c *catFile) read(name string, withContent bool) (*object, error) {
+	if strings.Contains(name, "\n") {
+		return nil, &errMissingObject{name: name}
+	}
+	if _, err := io.WriteString(c.stdin, name+"\n"); err != nil {
+		return nil, err
+	}
+
+	header, err := c.stdout.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	// header format: <oid> <type> <size>, or "<name> missing" / "<name> ambiguous",
+	// where name may itself contain spaces (e.g. HEAD:changelog/my entry.yml).
+	fields := strings.Fields(header)
+	if len(fields) == 0 || fields[len(fields)-1] == "missing" || fields[len(fields)-1] == "ambiguous" {
+		return nil, &errMissingObject{name: name}
+	}
+	if len(fields) != 3 || !objectIDPattern.MatchString(fields[0]) {
+		return nil, fmt.Errorf("unexpected git cat-file header %q", strings.TrimSpace(header))
+	}
+
+	obj := &object{id: fields[0], typ: fields[1]}
+	if !withContent {
+		return obj, nil
+	}
+
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git cat-file header %q", strings.TrimSpace(header))
+	}
+	obj.content = make([]byte, size+1)
+	if _, err := io.ReadFull(c.stdout, obj.content); err != nil {
+		return nil, err
+	}
+	obj.content = obj.content[:size]
+	return obj, nil
+}
+
+// lookup reads name with the --batch process, or only resolves it with the
+// --batch-check one. A process that failed is dropped so that the next lookup
+// restarts it.
+func (r *Repository) lookup(name string, withContent bool) (*object, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	process, mode := &r.check, "--batch-check"
+	if withContent {
+		process, mode = &r.batch, "--batch"
+	}
+	if *process == nil {
+		started, err := startCatFile(r.workingDir, mode)
+		if err != nil {
+			return nil, err
+		}
+		*process = started
+	}
+
+	obj, err := (*process).read(name, withContent)
+	if err != nil {
+		if _, missing := err.(*errMissingObject); !missing {
+			(*process).close()
+			*process = nil
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// rootPath returns file, relative to workingDir, relative to the repository
+// root in slash form.
+func (r *Repository) rootPath(file string) string {
+	r.mu.Lock()
+	if r.prefix == nil {
+		prefix := gitPrefix(r.workingDir)
+		r.prefix = &prefix
+	}
+	prefix := *r.prefix
+	r.mu.Unlock()
+
+	root := path.Clean(path.Join(filepath.ToSlash(prefix), filepath.ToSlash(normalizePath(file))))
+	if root == "." {
+		return ""
+	}
+	return root
+}
+
+// BlobHash returns the object ID content would have as a blob in the
+// repository, hashed with its object format (SHA-1 or SHA-256).
+func (r *Repository) BlobHash(content []byte) string {
+	r.mu.Lock()
+	if r.objectFormat == nil {
+		format := gitObjectFormat(r.workingDir)
+		r.objectFormat = &format
+	}
+	format := *r.objectFormat
+	r.mu.Unlock()
+
+	var h hash.Hash
+	if format == "sha256" {
+		h = sha256.New()
+	} else {
+		h = sha1.New()
+	}
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RefExists reports whether ref resolves to a commit in the repository.
+func (r *Repository) RefExists(ref string) bool {
+	_, err := r.lookup(ref+"^{commit}", false)
+	return err == nil
+}
+
+// IsAncestor reports whether commit is an ancestor of (i.e. reachable from)
+// ref. Answers are cached by the object IDs the two revisions resolve to.
+func (r *Repository) IsAncestor(commit, ref string) bool {
+	commitObj, err := r.lookup(commit+"^{commit}", false)
+	if err != nil {
+		return false
+	}
+	refObj, err := r.lookup(ref+"^{commit}", false)
+	if err != nil {
+		return false
+	}
+
+	key := [2]string{commitObj.id, refObj.id}
+	r.mu.Lock()
+	answer, ok := r.ancestors[key]
+	r.mu.Unlock()
+	if ok {
+		return answer
+	}
+
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", key[0], key[1])
+	cmd.Dir = r.workingDir
+	answer = cmd.Run() == nil
+
+	r.mu.Lock()
+	r.ancestors[key] = answer
+	r.mu.Unlock()
+	return answer
+}
+
+// CommitMessage returns the full message of the given commit.
+func (r *Repository) CommitMessage(commit string) (string, error) {
+	obj, err := r.lookup(commit+"^{commit}", true)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit message of %s: %v", commit, err)
+	}
+
+	// The headers end at the first empty line; signature headers continue on
+	// lines starting with a space, so they never contain one.
+	_, message, ok := bytes.Cut(obj.content, []byte("\n\n"))
+	if !ok {
+		return "", nil
+	}
+	return string(message), nil
+}
+
+// FindCherryPickSource returns the source commit SHA recorded by
+// `git cherry-pick -x` in the given commit's message (see the
+// FindCherryPickSource function).
+func (r *Repository) FindCherryPickSource(commit string) string {
+	message, err := r.CommitMessage(commit)
+	if err != nil {
+		return ""
+	}
+
+	matches := cherryPickTrailerPattern.FindAllStringSubmatch(message, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return matches[len(matches)-1][1]
+}
+
+// ReadFileAt returns the content of file, relative to workingDir, as of rev.
+func (r *Repository) ReadFileAt(rev, file string) ([]byte, error) {
+	obj, err := r.lookup(rev+":"+r.rootPath(file), true)
+	if err == nil && obj.typ != "blob" {
+		err = fmt.Errorf("not a file (%s)", obj.typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %v", file, rev, err)
+	}
+	return obj.content, nil
+}
+
+// walkTree calls fn with the path (relative to the repository root) and blob
+// ID of every file under root in the tree of rev. A missing root has no files.
+func (r *Repository) walkTree(rev, root string, fn func(file, id string)) error {
+	if root == "." {
+		root = ""
+	}
+	obj, err := r.lookup(rev+"^{tree}", false)
+	if err != nil {
+		return err
+	}
+	if root != "" {
+		obj, err = r.lookup(obj.id+":"+root, false)
+		if err != nil {
+			if _, missing := err.(*errMissingObject); missing {
+				return nil
+			}
+			return err
+		}
+	}
+
+	switch obj.typ {
+	case "blob":
+		fn(root, obj.id)
+		return nil
+	case "tree":
+		return r.walkSubtree(obj.id, root, fn)
+	default:
+		return nil
+	}
+}
+
+func (r *Repository) walkSubtree(id, dir string, fn func(file, id string)) error {
+	obj, err := r.lookup(id, true)
+	if err != nil {
+		return err
+	}
+
+	// tree entries: <mode> <name>\x00<raw object ID>
+	idLen := len(obj.id) / 2
+	content := obj.content
+	for len(content) > 0 {
+		header, rest, ok := bytes.Cut(content, []byte{0})
+		if !ok || len(rest) < idLen {
+			return fmt.Errorf("malformed tree %s", id)
+		}
+		mode, name, _ := strings.Cut(string(header), " ")
+		entryID := hex.EncodeToString(rest[:idLen])
+		content = rest[idLen:]
+
+		file := path.Join(dir, name)
+		switch mode {
+		case "40000":
+			if err := r.walkSubtree(entryID, file, fn); err != nil {
+				return err
+			}
+		case "160000":
+			// submodule commit
+		default:
+			fn(file, entryID)
+		}
+	}
+	return nil
+}
+
+// BlobsAt returns the blob IDs of the files under path (relative to the
+// repository root) in the tree of rev, mapped to their paths.
+func (r *Repository) BlobsAt(rev, root string) (map[string]string, error) {
+	blobs := make(map[string]string)
+	err := r.walkTree(rev, path.Clean(filepath.ToSlash(root)), func(file, id string) {
+		blobs[id] = file
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s at %s: %v", root, rev, err)
+	}
+	return blobs, nil
+}
+
+// ListFilesAt returns the files under paths (the whole of workingDir when
+// empty) in the tree of rev, relative to workingDir.
+func (r *Repository) ListFilesAt(rev string, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	prefix := r.rootPath(".")
+
+	seen := make(map[string]bool)
+	files := make([]string, 0)
+	for _, p := range paths {
+		err := r.walkTree(rev, r.rootPath(p), func(file, _ string) {
+			if prefix != "" {
+				file = strings.TrimPrefix(file, prefix+"/")
+			}
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, normalizePath(file))
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files at %s: %v", rev, err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
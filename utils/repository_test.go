@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	dir := newRepo(t)
+	first := commitFile(t, dir, "changelog/unreleased/kong/a.yml", "message: a\n", "add a")
+	second := commitFile(t, dir, "changelog/unreleased/kong/b.yml", "message: b\n", "add b\n\n(cherry picked from commit "+first+")")
+
+	repo := OpenRepository(filepath.Join(dir, "changelog"))
+	defer repo.Close()
+
+	if !repo.RefExists("HEAD") || repo.RefExists("no-such-branch") {
+		t.Fatal("RefExists() did not tell HEAD from a missing branch")
+	}
+	if !repo.IsAncestor(first, "HEAD") || repo.IsAncestor(second, first) {
+		t.Fatal("IsAncestor() answered wrongly")
+	}
+	if message, err := repo.CommitMessage(first); err != nil || message != "add a\n" {
+		t.Fatalf("CommitMessage() = %q, %v", message, err)
+	}
+	if got := repo.FindCherryPickSource(second); got != first {
+		t.Fatalf("FindCherryPickSource() = %q, want %q", got, first)
+	}
+
+	// Paths are relative to the working directory, a subdirectory here.
+	if content, err := repo.ReadFileAt(first, "unreleased/kong/a.yml"); err != nil || string(content) != "message: a\n" {
+		t.Fatalf("ReadFileAt() = %q, %v", content, err)
+	}
+	if _, err := repo.ReadFileAt(first, "unreleased/kong/b.yml"); err == nil {
+		t.Fatal("ReadFileAt(missing) returned no error")
+	}
+	files, err := repo.ListFilesAt("HEAD", []string{"unreleased"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"unreleased/kong/a.yml", "unreleased/kong/b.yml"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("ListFilesAt() = %v, want %v", files, want)
+	}
+
+	blobs, err := repo.BlobsAt("HEAD", "changelog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := blobs[repo.BlobHash([]byte("message: b\n"))]; got != "changelog/unreleased/kong/b.yml" {
+		t.Fatalf("BlobsAt() = %v", blobs)
+	}
+
+	// The processes restart after Close and see new commits.
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+	third := commitFile(t, dir, "changelog/unreleased/kong/c.yml", "message: c\n", "add c")
+	if !repo.IsAncestor(third, "HEAD") {
+		t.Fatal("IsAncestor() after Close did not see the new commit")
+	}
+}
+
+func TestRepositoryMissingPathWithSpace(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "changelog/unreleased/kong/a.yml", "message: a\n", "add a")
+
+	repo := OpenRepository(dir)
+	defer repo.Close()
+
+	// cat-file answers "<name> missing", which splits into as many fields as
+	// an object header when the name has one space.
+	if repo.RefExists("HEAD:changelog/unreleased/kong/my entry.yml") {
+		t.Fatal("RefExists() reported a missing path with a space as present")
+	}
+	if _, err := repo.ReadFileAt("HEAD", "changelog/unreleased/kong/my entry.yml"); err == nil {
+		t.Fatal("ReadFileAt(missing path with a space) returned no error")
+	}
+	if repo.batch == nil || repo.check == nil {
+		t.Fatal("a missing object dropped the cat-file process")
+	}
+	if content, err := repo.ReadFileAt("HEAD", "changelog/unreleased/kong/a.yml"); err != nil || string(content) != "message: a\n" {
+		t.Fatalf("ReadFileAt() after a missing object = %q, %v", content, err)
+	}
+}
+
+func TestCloseRepository(t *testing.T) {
+	dir := newRepo(t)
+	commitFile(t, dir, "changelog/unreleased/kong/a.yml", "message: a\n", "add a")
+	if _, err := ReadFileAt(dir, "HEAD", "changelog/unreleased/kong/a.yml"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := repositoryAt(dir)
+	if repo.batch == nil {
+		t.Fatal("ReadFileAt() did not start the shared cat-file process")
+	}
+	if err := CloseRepository(dir); err != nil {
+		t.Fatal(err)
+	}
+	if repo.batch != nil || repo.check != nil {
+		t.Fatal("CloseRepository() left the cat-file processes running")
+	}
+	if repositoryAt(dir) == repo {
+		t.Fatal("CloseRepository() kept the closed repository shared")
+	}
+	if err := CloseRepository(dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepositoryBlobHash(t *testing.T) {
+	for _, format := range []string{"sha1", "sha256"} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			runGit(t, dir, "init", "-q", "--object-format="+format)
+			commitFile(t, dir, "changelog/unreleased/kong/a.yml", "message: a\n", "add a")
+
+			repo := OpenRepository(dir)
+			defer repo.Close()
+			want := strings.TrimSpace(runGit(t, dir, "rev-parse", "HEAD:changelog/unreleased/kong/a.yml"))
+			if got := repo.BlobHash([]byte("message: a\n")); got != want {
+				t.Fatalf("BlobHash() = %s, want %s", got, want)
+			}
+		})
+	}
+}